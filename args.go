@@ -1,7 +1,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -77,25 +81,75 @@ type Argument interface {
 	Get() any
 }
 
+// DocGenerationArgument is an interface that allows documentation
+// generation for the argument
+type DocGenerationArgument interface {
+	Argument
+
+	// GetName returns the name of the argument
+	GetName() string
+
+	// GetDescription returns the description of the argument
+	GetDescription() string
+
+	// GetValue returns the default value of the argument as a string, or
+	// an empty string if it has none
+	GetValue() string
+
+	// GetDefaultText returns the default text for this argument
+	GetDefaultText() string
+
+	// GetEnvVars returns the env vars for this argument
+	GetEnvVars() []string
+
+	// IsDefaultVisible returns whether the default value should be shown in
+	// help text
+	IsDefaultVisible() bool
+
+	// TypeName returns the type of the values of the argument
+	TypeName() string
+
+	// IsMultiValue returns true for arguments which take several values
+	IsMultiValue() bool
+}
+
 type requiredArgument interface {
 	name() string
 	required() bool
 }
 
+// boundedArgument is implemented by arguments that know how many
+// positional values they consume
+type boundedArgument interface {
+	// maxValues returns the max number of values, -1 for unlimited
+	maxValues() int
+}
+
 // AnyArguments to differentiate between no arguments(nil) vs aleast one
 var AnyArguments = []Argument{
 	&StringArgs{
-		Max: -1,
+		Max:       -1,
+		UsageText: "[arguments...]",
 	},
 }
 
 type ArgumentBase[T any, C any, VC ValueCreator[T, C]] struct {
-	Name        string `json:"name"`      // the name of this argument
-	Value       T      `json:"value"`     // the default value of this argument
-	Destination *T     `json:"-"`         // the destination point for this argument
-	UsageText   string `json:"usageText"` // the usage text to show
-	Required    bool   `json:"required"`  // whether the argument is required or not
-	Config      C      `json:"config"`    // config for this argument similar to Flag Config
+	Name        string `json:"name"`         // the name of this argument
+	Value       T      `json:"value"`        // the default value of this argument
+	Destination *T     `json:"-"`            // the destination point for this argument
+	UsageText   string `json:"usageText"`    // the usage text to show
+	Description string `json:"description"`  // the description of this argument shown in help
+	DefaultText string `json:"defaultText"`  // the default value shown in help
+	HideDefault bool   `json:"hideDefault"`  // whether to hide the default value in help
+	Required    bool   `json:"required"`     // whether the argument is required or not
+	Config      C      `json:"config"`       // config for this argument similar to Flag Config
+	TakesFile   bool   `json:"takesFileArg"` // whether this argument takes a file argument, mainly for shell completion purposes
+
+	Sources   ValueSourceChain `json:"-"` // sources to load the argument value from when it isn't given
+	Validator func(T) error    `json:"-"` // custom function to validate the argument value
+
+	ShellComplete       ValueCompleteFunc   `json:"-"` // function returning the candidate values of this argument for shell completion
+	CompletionDirective CompletionDirective `json:"-"` // how the completion script should complete this argument
 
 	value *T
 }
@@ -112,6 +166,122 @@ func (a *ArgumentBase[T, C, VC]) required() bool {
 	return a.Required
 }
 
+// IsRequired returns whether the argument is required
+func (a *ArgumentBase[T, C, VC]) IsRequired() bool {
+	return a.Required
+}
+
+func (a *ArgumentBase[T, C, VC]) GetName() string {
+	return a.Name
+}
+
+// GetDescription returns the description of this argument
+func (a *ArgumentBase[T, C, VC]) GetDescription() string {
+	return a.Description
+}
+
+// GetDefaultText returns the default text for this argument
+func (a *ArgumentBase[T, C, VC]) GetDefaultText() string {
+	return a.DefaultText
+}
+
+// GetEnvVars returns the env vars for this argument
+func (a *ArgumentBase[T, C, VC]) GetEnvVars() []string {
+	return a.Sources.EnvKeys()
+}
+
+// IsDefaultVisible returns true if the default value should be shown in
+// help text
+func (a *ArgumentBase[T, C, VC]) IsDefaultVisible() bool {
+	return !a.HideDefault
+}
+
+// TypeName returns the type of the values of the argument
+func (a *ArgumentBase[T, C, VC]) TypeName() string {
+	return typeName(reflect.TypeOf(a.Value))
+}
+
+// GetChoices returns the allowed values for this argument, if any
+func (a *ArgumentBase[T, C, VC]) GetChoices() []string {
+	return configChoices(a.Config)
+}
+
+// String returns a readable representation of this argument, as shown in
+// the ARGUMENTS section of help text
+func (a *ArgumentBase[T, C, VC]) String() string {
+	return stringifyArgument(a)
+}
+
+// GetValue returns the default value of the argument as a string
+func (a *ArgumentBase[T, C, VC]) GetValue() string {
+	var vc VC
+	return vc.ToString(a.Value)
+}
+
+// IsMultiValue returns false since the argument takes a single value
+func (a *ArgumentBase[T, C, VC]) IsMultiValue() bool {
+	return false
+}
+
+func (a *ArgumentBase[T, C, VC]) maxValues() int {
+	return 1
+}
+
+// reset restores the argument to the state it had before being parsed
+func (a *ArgumentBase[T, C, VC]) reset() {
+	a.value = nil
+}
+
+// clone returns a copy of the argument without the state of a previous run
+func (a *ArgumentBase[T, C, VC]) clone() Argument {
+	c := *a
+	c.Sources.Chain = slices.Clone(a.Sources.Chain)
+	c.reset()
+	return &c
+}
+
+func (a *ArgumentBase[T, C, VC]) valueSources() ValueSourceChain {
+	return a.Sources
+}
+
+// validateDefinition checks the default value against the values the
+// argument accepts, and against the Validator unless the argument is
+// required and so never left to its default or the default is the zero
+// value
+func (a *ArgumentBase[T, C, VC]) validateDefinition() error {
+	var vc VC
+	if dc, ok := vc.Create(a.Value, new(T), a.Config).(defaultChecker); ok {
+		if err := dc.checkDefault(); err != nil {
+			return fmt.Errorf("invalid default value for argument %s: %w", a.Name, err)
+		}
+	}
+	if a.Validator == nil || a.Required || reflect.ValueOf(&a.Value).Elem().IsZero() {
+		return nil
+	}
+	if err := a.Validator(a.Value); err != nil {
+		return fmt.Errorf("invalid default value for argument %s: %w", a.Name, err)
+	}
+	return nil
+}
+
+// CompleteValue returns the candidate values of this argument for shell
+// completion, which are the allowed values of the argument unless a
+// ShellComplete function is set
+func (a *ArgumentBase[T, C, VC]) CompleteValue(ctx context.Context, cmd *Command) []string {
+	if a.ShellComplete != nil {
+		return a.ShellComplete(ctx, cmd)
+	}
+	return configChoices(a.Config)
+}
+
+// GetCompletionDirective returns how the completion script should complete
+// this argument
+func (a *ArgumentBase[T, C, VC]) GetCompletionDirective() CompletionDirective {
+	d := a.CompletionDirective
+	d.Files = d.Files || a.TakesFile
+	return d
+}
+
 func (a *ArgumentBase[T, C, VC]) Usage() string {
 	if a.UsageText != "" {
 		return a.UsageText
@@ -127,7 +297,21 @@ func (a *ArgumentBase[T, C, VC]) Usage() string {
 func (a *ArgumentBase[T, C, VC]) Parse(s []string) ([]string, error) {
 	tracef("calling arg%[1] parse with args %[2]", a.Name, s)
 
-	if a.Required && len(s) == 0 {
+	var (
+		val    string
+		source ValueSource
+		found  = len(s) > 0
+	)
+	if found {
+		val = s[0]
+	} else {
+		var err error
+		if val, source, found, err = a.Sources.LookupWithSourceE(); err != nil {
+			return s, argSourceError(a.Name, err)
+		}
+	}
+
+	if a.Required && !found {
 		return s, &errRequiredArguments{missingArguments: []string{a.Name}}
 	}
 
@@ -137,12 +321,26 @@ func (a *ArgumentBase[T, C, VC]) Parse(s []string) ([]string, error) {
 	a.value = &t
 
 	tracef("attempting arg%[1] parse", &a.Name)
-	if len(s) > 0 {
-		if err := value.Set(s[0]); err != nil {
-			return s, fmt.Errorf("invalid value %q for argument %s: %v", s[0], a.Name, err)
+	if found {
+		if err := value.Set(val); err != nil {
+			return s, &ArgumentError{Name: a.Name, Values: []string{val}, Source: source, Err: err}
+		}
+		// values wrapping a user Value, as GenericArg does, update it in
+		// place and return the value it holds instead
+		if v, ok := value.Get().(T); ok {
+			*a.value = v
 		}
-		*a.value = value.Get().(T)
 		tracef("set arg%[1] one value", a.Name, *a.value)
+
+		if a.Validator != nil {
+			if err := a.Validator(*a.value); err != nil {
+				return s, &ArgumentError{Name: a.Name, Values: []string{val}, Source: source, Err: err}
+			}
+		}
+	} else if dc, ok := value.(defaultChecker); ok {
+		if err := dc.checkDefault(); err != nil {
+			return s, fmt.Errorf("invalid default value for argument %s: %w", a.Name, err)
+		}
 	}
 
 	if a.Destination != nil {
@@ -165,13 +363,23 @@ func (a *ArgumentBase[T, C, VC]) Get() any {
 
 // ArgumentsBase is a base type for slice arguments
 type ArgumentsBase[T any, C any, VC ValueCreator[T, C]] struct {
-	Name        string `json:"name"`      // the name of this argument
-	Value       T      `json:"value"`     // the default value of this argument
-	Destination *[]T   `json:"-"`         // the destination point for this argument
-	UsageText   string `json:"usageText"` // the usage text to show
-	Min         int    `json:"minTimes"`  // the min num of occurrences of this argument
-	Max         int    `json:"maxTimes"`  // the max num of occurrences of this argument, set to -1 for unlimited
-	Config      C      `json:"config"`    // config for this argument similar to Flag Config
+	Name        string `json:"name"`         // the name of this argument
+	Value       T      `json:"value"`        // the default value of this argument
+	Destination *[]T   `json:"-"`            // the destination point for this argument
+	UsageText   string `json:"usageText"`    // the usage text to show
+	Description string `json:"description"`  // the description of this argument shown in help
+	DefaultText string `json:"defaultText"`  // the default value shown in help
+	HideDefault bool   `json:"hideDefault"`  // whether to hide the default value in help
+	Min         int    `json:"minTimes"`     // the min num of occurrences of this argument
+	Max         int    `json:"maxTimes"`     // the max num of occurrences of this argument, set to -1 for unlimited
+	Config      C      `json:"config"`       // config for this argument similar to Flag Config
+	TakesFile   bool   `json:"takesFileArg"` // whether this argument takes a file argument, mainly for shell completion purposes
+
+	Sources   ValueSourceChain `json:"-"` // sources to load the argument values from when none is given
+	Validator func([]T) error  `json:"-"` // custom function to validate the argument values
+
+	ShellComplete       ValueCompleteFunc   `json:"-"` // function returning the candidate values of this argument for shell completion
+	CompletionDirective CompletionDirective `json:"-"` // how the completion script should complete this argument
 
 	values []T
 }
@@ -180,6 +388,113 @@ func (a *ArgumentsBase[T, C, VC]) HasName(s string) bool {
 	return s == a.Name
 }
 
+func (a *ArgumentsBase[T, C, VC]) maxValues() int {
+	return a.Max
+}
+
+// IsRequired returns whether at least one value of the argument is required
+func (a *ArgumentsBase[T, C, VC]) IsRequired() bool {
+	return a.Min > 0
+}
+
+func (a *ArgumentsBase[T, C, VC]) GetName() string {
+	return a.Name
+}
+
+// GetDescription returns the description of this argument
+func (a *ArgumentsBase[T, C, VC]) GetDescription() string {
+	return a.Description
+}
+
+// GetDefaultText returns the default text for this argument
+func (a *ArgumentsBase[T, C, VC]) GetDefaultText() string {
+	return a.DefaultText
+}
+
+// GetEnvVars returns the env vars for this argument
+func (a *ArgumentsBase[T, C, VC]) GetEnvVars() []string {
+	return a.Sources.EnvKeys()
+}
+
+// IsDefaultVisible returns true if the default value should be shown in
+// help text
+func (a *ArgumentsBase[T, C, VC]) IsDefaultVisible() bool {
+	return !a.HideDefault
+}
+
+// TypeName returns the type of the values of the argument
+func (a *ArgumentsBase[T, C, VC]) TypeName() string {
+	return typeName(reflect.TypeOf(a.Value))
+}
+
+// GetChoices returns the allowed values for this argument, if any
+func (a *ArgumentsBase[T, C, VC]) GetChoices() []string {
+	return configChoices(a.Config)
+}
+
+// String returns a readable representation of this argument, as shown in
+// the ARGUMENTS section of help text
+func (a *ArgumentsBase[T, C, VC]) String() string {
+	return stringifyArgument(a)
+}
+
+// GetValue returns an empty string since the argument has no other default
+// than an empty list of values
+func (a *ArgumentsBase[T, C, VC]) GetValue() string {
+	return ""
+}
+
+// IsMultiValue returns true unless the argument takes at most one value
+func (a *ArgumentsBase[T, C, VC]) IsMultiValue() bool {
+	return a.Max != 1
+}
+
+// reset restores the argument to the state it had before being parsed
+func (a *ArgumentsBase[T, C, VC]) reset() {
+	a.values = nil
+}
+
+// validateDefinition checks the bounds of the number of values
+func (a *ArgumentsBase[T, C, VC]) validateDefinition() error {
+	if a.Max == 0 {
+		return fmt.Errorf("args %s has max 0", a.Name)
+	}
+	if a.Max != -1 && a.Min > a.Max {
+		return fmt.Errorf("args %s has min[%d] > max[%d]", a.Name, a.Min, a.Max)
+	}
+	return nil
+}
+
+// clone returns a copy of the argument without the state of a previous run
+func (a *ArgumentsBase[T, C, VC]) clone() Argument {
+	c := *a
+	c.Sources.Chain = slices.Clone(a.Sources.Chain)
+	c.reset()
+	return &c
+}
+
+func (a *ArgumentsBase[T, C, VC]) valueSources() ValueSourceChain {
+	return a.Sources
+}
+
+// CompleteValue returns the candidate values of this argument for shell
+// completion, which are the allowed values of the argument unless a
+// ShellComplete function is set
+func (a *ArgumentsBase[T, C, VC]) CompleteValue(ctx context.Context, cmd *Command) []string {
+	if a.ShellComplete != nil {
+		return a.ShellComplete(ctx, cmd)
+	}
+	return configChoices(a.Config)
+}
+
+// GetCompletionDirective returns how the completion script should complete
+// this argument
+func (a *ArgumentsBase[T, C, VC]) GetCompletionDirective() CompletionDirective {
+	d := a.CompletionDirective
+	d.Files = d.Files || a.TakesFile
+	return d
+}
+
 func (a *ArgumentsBase[T, C, VC]) Usage() string {
 	if a.UsageText != "" {
 		return a.UsageText
@@ -208,25 +523,44 @@ func (a *ArgumentsBase[T, C, VC]) Parse(s []string) ([]string, error) {
 	}
 
 	count := 0
-	var vc VC
-	var t T
-	value := vc.Create(a.Value, &t, a.Config)
 	a.values = []T{}
 
 	tracef("attempting arg%[1] parse", &a.Name)
 	for _, arg := range s {
-		if err := value.Set(arg); err != nil {
-			return s, fmt.Errorf("invalid value %q for argument %s: %v", arg, a.Name, err)
+		v, err := a.parseValue(arg)
+		if err != nil {
+			return s, &ArgumentError{Name: a.Name, Values: []string{arg}, Err: err}
 		}
-		tracef("set arg%[1] one value", &a.Name, value.Get().(T))
-		a.values = append(a.values, value.Get().(T))
+		tracef("set arg%[1] one value", &a.Name, v)
+		a.values = append(a.values, v)
 		count++
 		if count >= a.Max && a.Max > -1 {
 			break
 		}
 	}
-	if count < a.Min {
-		return s, fmt.Errorf("sufficient count of arg %s not provided, given %d expected %d", a.Name, count, a.Min)
+	vals := s[:count]
+
+	var source ValueSource
+	if count == 0 {
+		val, src, found, err := a.Sources.LookupWithSourceE()
+		if err != nil {
+			return s, argSourceError(a.Name, err)
+		}
+		if found {
+			source = src
+			if vals, err = a.parseSourceValues(val, src); err != nil {
+				return s, err
+			}
+		}
+	}
+
+	if given := len(a.values); given < a.Min {
+		return s, fmt.Errorf("sufficient count of arg %s not provided, given %d expected %d", a.Name, given, a.Min)
+	}
+	if a.Validator != nil && len(a.values) > 0 {
+		if err := a.Validator(a.values); err != nil {
+			return s, &ArgumentError{Name: a.Name, Values: vals, Source: source, Err: err}
+		}
 	}
 
 	if a.Destination != nil {
@@ -237,6 +571,46 @@ func (a *ArgumentsBase[T, C, VC]) Parse(s []string) ([]string, error) {
 	return s[count:], nil
 }
 
+// parseValue parses one value of the argument, starting from the default
+// Value so that values such as maps don't accumulate the previous ones
+func (a *ArgumentsBase[T, C, VC]) parseValue(s string) (T, error) {
+	var vc VC
+	var t T
+	value := vc.Create(a.Value, &t, a.Config)
+	if err := value.Set(s); err != nil {
+		return t, err
+	}
+	return value.Get().(T), nil
+}
+
+// parseSourceValues parses the comma separated values read from the source
+// src, which may not be more than the Max of the argument
+func (a *ArgumentsBase[T, C, VC]) parseSourceValues(val string, src ValueSource) ([]string, error) {
+	var vals []string
+	for _, v := range strings.Split(val, defaultSliceFlagSeparator) {
+		if v = strings.TrimSpace(v); v != "" {
+			vals = append(vals, v)
+		}
+	}
+	if a.Max > -1 && len(vals) > a.Max {
+		return nil, &ArgumentError{
+			Name:   a.Name,
+			Values: vals,
+			Source: src,
+			Err:    fmt.Errorf("got %d values, at most %d allowed", len(vals), a.Max),
+		}
+	}
+
+	for _, v := range vals {
+		t, err := a.parseValue(v)
+		if err != nil {
+			return nil, &ArgumentError{Name: a.Name, Values: []string{v}, Source: src, Err: err}
+		}
+		a.values = append(a.values, t)
+	}
+	return vals, nil
+}
+
 func (a *ArgumentsBase[T, C, VC]) Get() any {
 	if a.values != nil {
 		return a.values
@@ -245,6 +619,8 @@ func (a *ArgumentsBase[T, C, VC]) Get() any {
 }
 
 type (
+	BoolArg       = ArgumentBase[bool, BoolConfig, boolValue]
+	DurationArg   = ArgumentBase[time.Duration, NoConfig, durationValue]
 	FloatArg      = ArgumentBase[float64, NoConfig, floatValue[float64]]
 	Float32Arg    = ArgumentBase[float32, NoConfig, floatValue[float32]]
 	Float64Arg    = ArgumentBase[float64, NoConfig, floatValue[float64]]
@@ -254,6 +630,8 @@ type (
 	Int32Arg      = ArgumentBase[int32, IntegerConfig, intValue[int32]]
 	Int64Arg      = ArgumentBase[int64, IntegerConfig, intValue[int64]]
 	StringArg     = ArgumentBase[string, StringConfig, stringValue]
+	EnumArg       = ArgumentBase[string, EnumConfig, enumValue]
+	GenericArg    = ArgumentBase[Value, NoConfig, genericValue]
 	StringMapArgs = ArgumentBase[map[string]string, StringConfig, StringMap]
 	TimestampArg  = ArgumentBase[time.Time, TimestampConfig, timestampValue]
 	UintArg       = ArgumentBase[uint, IntegerConfig, uintValue[uint]]
@@ -262,21 +640,25 @@ type (
 	Uint32Arg     = ArgumentBase[uint32, IntegerConfig, uintValue[uint32]]
 	Uint64Arg     = ArgumentBase[uint64, IntegerConfig, uintValue[uint64]]
 
-	FloatArgs     = ArgumentsBase[float64, NoConfig, floatValue[float64]]
-	Float32Args   = ArgumentsBase[float32, NoConfig, floatValue[float32]]
-	Float64Args   = ArgumentsBase[float64, NoConfig, floatValue[float64]]
-	IntArgs       = ArgumentsBase[int, IntegerConfig, intValue[int]]
-	Int8Args      = ArgumentsBase[int8, IntegerConfig, intValue[int8]]
-	Int16Args     = ArgumentsBase[int16, IntegerConfig, intValue[int16]]
-	Int32Args     = ArgumentsBase[int32, IntegerConfig, intValue[int32]]
-	Int64Args     = ArgumentsBase[int64, IntegerConfig, intValue[int64]]
-	StringArgs    = ArgumentsBase[string, StringConfig, stringValue]
-	TimestampArgs = ArgumentsBase[time.Time, TimestampConfig, timestampValue]
-	UintArgs      = ArgumentsBase[uint, IntegerConfig, uintValue[uint]]
-	Uint8Args     = ArgumentsBase[uint8, IntegerConfig, uintValue[uint8]]
-	Uint16Args    = ArgumentsBase[uint16, IntegerConfig, uintValue[uint16]]
-	Uint32Args    = ArgumentsBase[uint32, IntegerConfig, uintValue[uint32]]
-	Uint64Args    = ArgumentsBase[uint64, IntegerConfig, uintValue[uint64]]
+	BoolArgs       = ArgumentsBase[bool, BoolConfig, boolValue]
+	DurationArgs   = ArgumentsBase[time.Duration, NoConfig, durationValue]
+	FloatArgs      = ArgumentsBase[float64, NoConfig, floatValue[float64]]
+	Float32Args    = ArgumentsBase[float32, NoConfig, floatValue[float32]]
+	Float64Args    = ArgumentsBase[float64, NoConfig, floatValue[float64]]
+	IntArgs        = ArgumentsBase[int, IntegerConfig, intValue[int]]
+	Int8Args       = ArgumentsBase[int8, IntegerConfig, intValue[int8]]
+	Int16Args      = ArgumentsBase[int16, IntegerConfig, intValue[int16]]
+	Int32Args      = ArgumentsBase[int32, IntegerConfig, intValue[int32]]
+	Int64Args      = ArgumentsBase[int64, IntegerConfig, intValue[int64]]
+	StringArgs     = ArgumentsBase[string, StringConfig, stringValue]
+	EnumArgs       = ArgumentsBase[string, EnumConfig, enumValue]
+	StringMapsArgs = ArgumentsBase[map[string]string, StringConfig, StringMap]
+	TimestampArgs  = ArgumentsBase[time.Time, TimestampConfig, timestampValue]
+	UintArgs       = ArgumentsBase[uint, IntegerConfig, uintValue[uint]]
+	Uint8Args      = ArgumentsBase[uint8, IntegerConfig, uintValue[uint8]]
+	Uint16Args     = ArgumentsBase[uint16, IntegerConfig, uintValue[uint16]]
+	Uint32Args     = ArgumentsBase[uint32, IntegerConfig, uintValue[uint32]]
+	Uint64Args     = ArgumentsBase[uint64, IntegerConfig, uintValue[uint64]]
 )
 
 func (c *Command) getArgValue(name string) any {
@@ -308,6 +690,38 @@ func (c *Command) StringArgs(name string) []string {
 	return arg[[]string](name, c)
 }
 
+func (c *Command) BoolArg(name string) bool {
+	return arg[bool](name, c)
+}
+
+func (c *Command) BoolArgs(name string) []bool {
+	return arg[[]bool](name, c)
+}
+
+func (c *Command) DurationArg(name string) time.Duration {
+	return arg[time.Duration](name, c)
+}
+
+func (c *Command) DurationArgs(name string) []time.Duration {
+	return arg[[]time.Duration](name, c)
+}
+
+func (c *Command) EnumArg(name string) string {
+	return arg[string](name, c)
+}
+
+func (c *Command) EnumArgs(name string) []string {
+	return arg[[]string](name, c)
+}
+
+func (c *Command) GenericArg(name string) Value {
+	return arg[Value](name, c)
+}
+
+func (c *Command) StringMapsArgs(name string) []map[string]string {
+	return arg[[]map[string]string](name, c)
+}
+
 func (c *Command) FloatArg(name string) float64 {
 	return arg[float64](name, c)
 }
@@ -197,6 +197,65 @@ func TestArgsUintSliceTypes(t *testing.T) {
 	r.Error(cmd.Run(buildTestContext(t), []string{"foo", "10", "20.0"}))
 }
 
+func TestArgsBoolDurationTypes(t *testing.T) {
+	cmd := buildMinimalTestCommand()
+	cmd.Arguments = []Argument{
+		&BoolArg{Name: "ba"},
+		&DurationArg{Name: "da"},
+		&BoolArgs{Name: "bas", Max: 2},
+		&DurationArgs{Name: "das", Max: -1},
+	}
+
+	r := require.New(t)
+	r.NoError(cmd.Run(buildTestContext(t), []string{"foo", "true", "1m", "false", "t", "1s", "2h"}))
+	r.True(cmd.BoolArg("ba"))
+	r.Equal(time.Minute, cmd.DurationArg("da"))
+	r.Equal([]bool{false, true}, cmd.BoolArgs("bas"))
+	r.Equal([]time.Duration{time.Second, 2 * time.Hour}, cmd.DurationArgs("das"))
+	r.False(cmd.BoolArg("da"))
+	r.Equal(time.Duration(0), cmd.DurationArg("ba"))
+
+	r.Error(cmd.Run(buildTestContext(t), []string{"foo", "yes"}))
+	r.Error(cmd.Run(buildTestContext(t), []string{"foo", "true", "1 minute"}))
+}
+
+func TestArgsStringMapTypes(t *testing.T) {
+	var labels map[string]string
+	cmd := buildMinimalTestCommand()
+	cmd.Arguments = []Argument{
+		&StringMapArgs{Name: "labels", Destination: &labels},
+		&StringMapsArgs{Name: "envs", Max: -1},
+	}
+
+	r := require.New(t)
+	r.NoError(cmd.Run(buildTestContext(t), []string{"foo", "a=1,b=2", "c=3", "d=4,e=5"}))
+	r.Equal(map[string]string{"a": "1", "b": "2"}, labels)
+	r.Equal([]map[string]string{{"c": "3"}, {"d": "4", "e": "5"}}, cmd.StringMapsArgs("envs"))
+	r.Nil(cmd.StringMapsArgs("labels"))
+}
+
+func TestArgsGenericType(t *testing.T) {
+	p := &Parser{"default", "value"}
+	cmd := buildMinimalTestCommand()
+	cmd.Arguments = []Argument{
+		&GenericArg{Name: "pair", Value: p},
+		&EnumArgs{Name: "modes", Max: -1, Config: EnumConfig{Choices: []string{"fast", "safe"}}},
+	}
+
+	r := require.New(t)
+	r.NoError(cmd.Run(buildTestContext(t), []string{"foo"}))
+	r.Same(p, cmd.GenericArg("pair"))
+	r.Equal(&Parser{"default", "value"}, p)
+
+	r.NoError(cmd.Run(buildTestContext(t), []string{"foo", "a,b", "safe", "fast"}))
+	r.Same(p, cmd.GenericArg("pair"))
+	r.Equal(&Parser{"a", "b"}, p)
+	r.Equal([]string{"safe", "fast"}, cmd.EnumArgs("modes"))
+	r.Nil(cmd.GenericArg("modes"))
+
+	r.ErrorContains(cmd.Run(buildTestContext(t), []string{"foo", "a"}), `invalid value "a" for argument pair: invalid format`)
+}
+
 func TestArgumentsRootCommand(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -803,3 +862,143 @@ func TestUnboundedArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestArgValidator(t *testing.T) {
+	errNegative := errors.New("must not be negative")
+	positive := func(i int) error {
+		if i < 0 {
+			return errNegative
+		}
+		return nil
+	}
+	allPositive := func(is []int) error {
+		for _, i := range is {
+			if err := positive(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			args: []string{"foo", "--", "1", "2", "3"},
+		},
+		{
+			name:        "invalid single value",
+			args:        []string{"foo", "--", "-1", "2", "3"},
+			expectedErr: `invalid value "-1" for argument count: must not be negative`,
+		},
+		{
+			name:        "invalid multiple values",
+			args:        []string{"foo", "--", "1", "2", "-3"},
+			expectedErr: `invalid values ["2" "-3"] for argument sizes: must not be negative`,
+		},
+		{
+			name:        "unparsable value",
+			args:        []string{"foo", "--", "1", "two"},
+			expectedErr: `invalid value "two" for argument sizes: strconv.ParseInt: parsing "two": invalid syntax`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := buildMinimalTestCommand()
+			cmd.Arguments = []Argument{
+				&IntArg{Name: "count", Validator: positive},
+				&IntArgs{Name: "sizes", Max: -1, Validator: allPositive},
+			}
+
+			err := cmd.Run(buildTestContext(t), test.args)
+			if test.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, test.expectedErr)
+
+			var argErr *ArgumentError
+			require.ErrorAs(t, err, &argErr)
+			if test.name != "unparsable value" {
+				require.ErrorIs(t, err, errNegative)
+			}
+		})
+	}
+}
+
+func TestArgSources(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		expected    string
+		expectedAll []string
+		expectedErr string
+	}{
+		{
+			name:        "given args override sources",
+			args:        []string{"foo", "bar", "baz"},
+			env:         map[string]string{"APP_NAME": "env", "APP_FILES": "a,b"},
+			expected:    "bar",
+			expectedAll: []string{"baz"},
+		},
+		{
+			name:        "omitted args are read from sources",
+			args:        []string{"foo"},
+			env:         map[string]string{"APP_NAME": "env", "APP_FILES": "a, b"},
+			expected:    "env",
+			expectedAll: []string{"a", "b"},
+		},
+		{
+			name:        "omitted required arg without source",
+			args:        []string{"foo"},
+			expectedErr: `Required argument "name" not set`,
+		},
+		{
+			name:        "too many values from source",
+			args:        []string{"foo", "bar"},
+			env:         map[string]string{"APP_FILES": "a,b,c"},
+			expectedErr: `invalid values ["a" "b" "c"] for argument files from environment variable "APP_FILES": got 3 values, at most 2 allowed`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+
+			cmd := buildMinimalTestCommand()
+			cmd.Arguments = []Argument{
+				&StringArg{Name: "name", Required: true, Sources: EnvVars("APP_NAME")},
+				&StringArgs{Name: "files", Max: 2, Sources: EnvVars("APP_FILES")},
+			}
+
+			err := cmd.Run(buildTestContext(t), test.args)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, cmd.StringArg("name"))
+			require.Equal(t, test.expectedAll, cmd.StringArgs("files"))
+		})
+	}
+}
+
+func TestArgSourceError(t *testing.T) {
+	cmd := buildMinimalTestCommand()
+	cmd.Arguments = []Argument{
+		&StringArg{Name: "input", Sources: Files(t.TempDir())},
+	}
+
+	err := cmd.Run(buildTestContext(t), []string{"foo"})
+	var vsErr *ValueSourceError
+	require.ErrorAs(t, err, &vsErr)
+	require.Equal(t, "input", vsErr.Argument)
+	require.ErrorContains(t, err, "for argument input: ")
+}
@@ -2,15 +2,52 @@ $fn = $($MyInvocation.MyCommand.Name)
 $name = $fn -replace "(.*)\.ps1$", '$1'
 Register-ArgumentCompleter -Native -CommandName $name -ScriptBlock {
     param($commandName, $wordToComplete, $cursorPosition)
-    $other = "$wordToComplete --generate-shell-completion"
-    Invoke-Expression $other | ForEach-Object {
-        $parts = $_.Split(':', 2)
+    $other = "$wordToComplete --generate-shell-completion=2"
+    $lines = @(Invoke-Expression $other)
+
+    # The optional last line starting with ":" holds the directives.
+    $directives = @()
+    if ($lines.Count -gt 0 -and "$($lines[-1])".StartsWith(':')) {
+        $directives = -split "$($lines[-1])".Substring(1)
+        $lines = @($lines | Select-Object -SkipLast 1)
+    }
+
+    $results = @($lines | Where-Object { $_ } | ForEach-Object {
+        # Colons in the value are escaped as "\:".
+        $parts = $_.Replace('\:', [char]0x1f).Split(':', 2)
+        $completion = $parts[0].Trim().Replace([char]0x1f, ':')
         if ($parts.Count -eq 2) {
-            $completion = $parts[0].Trim()
-            $description = $parts[1].Trim()
+            $description = $parts[1].Trim().Replace([char]0x1f, ':')
             [System.Management.Automation.CompletionResult]::new($completion, $completion, 'ParameterValue', $description)
         } else {
-            [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+            [System.Management.Automation.CompletionResult]::new($completion, $completion, 'ParameterValue', $completion)
+        }
+    })
+    if ($directives -notcontains 'keeporder') {
+        $results = @($results | Sort-Object -Property CompletionText)
+    }
+    $results
+
+    foreach ($directive in $directives) {
+        if ($directive -eq 'files' -or $directive.StartsWith('files=')) {
+            $extensions = @()
+            if ($directive.StartsWith('files=')) {
+                $extensions = $directive.Substring(6).Split(',') | ForEach-Object { ".$_" }
+            }
+            [System.Management.Automation.CompletionCompleters]::CompleteFilename($commandName) | Where-Object {
+                $extensions.Count -eq 0 -or $_.ResultType -eq 'ProviderContainer' -or
+                    $extensions -contains [System.IO.Path]::GetExtension($_.ListItemText)
+            }
+        } elseif ($directive -eq 'dirs') {
+            [System.Management.Automation.CompletionCompleters]::CompleteFilename($commandName) | Where-Object {
+                $_.ResultType -eq 'ProviderContainer'
+            }
         }
     }
+
+    # Without candidates PowerShell falls back to file completion, which an
+    # empty result prevents.
+    if ($results.Count -eq 0 -and $directives -contains 'nofiles') {
+        ''
+    }
 }
\ No newline at end of file
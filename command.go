@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"maps"
 	"slices"
+	"sort"
 	"strings"
 )
 
@@ -111,6 +113,11 @@ type Command struct {
 	DisableSliceFlagSeparator bool `json:"disableSliceFlagSeparator"`
 	// MapFlagKeyValueSeparator is used to customize the separator for MapFlag, the default is "="
 	MapFlagKeyValueSeparator string `json:"mapFlagKeyValueSeparator"`
+	// EnvPrefix, when set on the root command, gives every flag without an
+	// environment variable one named after the prefix, the path of the
+	// command and the flag, e.g. MYAPP_DEPLOY_DRY_RUN for the dry-run flag
+	// of the deploy command
+	EnvPrefix string `json:"envPrefix"`
 	// Boolean to enable short-option handling so user can combine several
 	// single-character bool arguments into one
 	// i.e. foobar -o -v -> foobar -ov
@@ -134,9 +141,18 @@ type Command struct {
 	MutuallyExclusiveFlags []MutuallyExclusiveFlags `json:"mutuallyExclusiveFlags"`
 	// Arguments to parse for this command
 	Arguments []Argument `json:"arguments"`
-	// Whether to read arguments from stdin
+	// Whether to read arguments from stdin, split into words with the
+	// quoting rules of SplitShellWords. Arguments following a "--" are
+	// ignored.
 	// applicable to root command only
 	ReadArgsFromStdin bool `json:"readArgsFromStdin"`
+	// Whether to replace @file arguments by the arguments read from file,
+	// split into words with the quoting rules of SplitShellWords. Files may
+	// contain @file arguments in turn. An argument starting with @@ stands
+	// for itself without its first @, and arguments following a "--" are
+	// left as they are.
+	// applicable to root command only
+	ExpandResponseFiles bool `json:"expandResponseFiles"`
 	// StopOnNthArg provides v2-like behavior for specific commands by stopping
 	// flag parsing after N positional arguments are encountered. When set to N,
 	// all remaining arguments after the Nth positional argument will be treated
@@ -165,10 +181,16 @@ type Command struct {
 	didSetupDefaults bool
 	// whether in shell completion mode
 	shellCompletion bool
+	// version of the completion output asked for by the completion script
+	completionVersion int
+	// candidates collected by Complete instead of being printed
+	completionCandidates *[]Candidate
 	// whether global help flag was added
 	globaHelpFlagAdded bool
 	// whether global version flag was added
 	globaVersionFlagAdded bool
+	// generated help flag
+	helpFlag Flag
 	// generated root version flag
 	versionFlag Flag
 	// whether this is a completion command
@@ -300,6 +322,18 @@ func (cmd *Command) appendFlag(fl Flag) {
 	}
 }
 
+// VisibleArguments returns a slice of the named Arguments which can
+// describe themselves in help text
+func (cmd *Command) VisibleArguments() []Argument {
+	var args []Argument
+	for _, arg := range cmd.Arguments {
+		if da, ok := arg.(DocGenerationArgument); ok && da.GetName() != "" {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
 // VisiblePersistentFlags returns a slice of [LocalFlag] with Persistent=true and Hidden=false.
 func (cmd *Command) VisiblePersistentFlags() []Flag {
 	if cmd.isCompletionCommand {
@@ -480,9 +514,16 @@ func (cmd *Command) checkRequiredArguments() requiredArgumentsErr {
 
 	for index, arg := range cmd.Arguments {
 		requiredArg, ok := arg.(requiredArgument)
-		if ok && requiredArg.required() && index >= providedArguments {
-			missingArguments = append(missingArguments, requiredArg.name())
+		if !ok || !requiredArg.required() || index < providedArguments {
+			continue
+		}
+		if sa, ok := arg.(sourcedFlags); ok {
+			vs := sa.valueSources()
+			if _, found := vs.Lookup(); found {
+				continue
+			}
 		}
+		missingArguments = append(missingArguments, requiredArg.name())
 	}
 
 	if len(missingArguments) != 0 {
@@ -521,11 +562,15 @@ func (cmd *Command) NumFlags() int {
 func (cmd *Command) setMultiValueParsingConfig(f Flag) {
 	tracef("setMultiValueParsingConfig %T, %+v", f, f)
 	if cf, ok := f.(multiValueParsingConfigSetter); ok {
-		cf.setMultiValueParsingConfig(multiValueParsingConfig{
-			SliceFlagSeparator:        cmd.SliceFlagSeparator,
-			DisableSliceFlagSeparator: cmd.DisableSliceFlagSeparator,
-			MapFlagKeyValueSeparator:  cmd.MapFlagKeyValueSeparator,
-		})
+		cf.setMultiValueParsingConfig(cmd.multiValueParsingConfig())
+	}
+}
+
+func (cmd *Command) multiValueParsingConfig() multiValueParsingConfig {
+	return multiValueParsingConfig{
+		SliceFlagSeparator:        cmd.SliceFlagSeparator,
+		DisableSliceFlagSeparator: cmd.DisableSliceFlagSeparator,
+		MapFlagKeyValueSeparator:  cmd.MapFlagKeyValueSeparator,
 	}
 }
 
@@ -641,6 +686,180 @@ func (cmd *Command) Walk(fn func(*Command) error) error {
 	return nil
 }
 
+// cloneable is implemented by flags and arguments which can copy
+// themselves without the state of a previous run
+type cloneable[T any] interface {
+	clone() T
+}
+
+// Clone returns a deep copy of cmd and its sub-commands, along with their
+// flags, mutually exclusive flag groups and arguments, without the state
+// of any previous run. The copy is detached from the parent of cmd and
+// can be run independently of cmd, e.g. from another goroutine.
+//
+// Functions, readers and writers are shared with the copy, as are the
+// pointers set on flags and arguments such as Destination and the Value
+// of a GenericFlag. Flags and arguments of types which do not come with
+// this package are shared too. Value sources keeping state for a run,
+// such as JSONConfig, DotEnv and ExecSource, are copied, while other
+// sources are shared.
+func (cmd *Command) Clone() *Command {
+	flags := map[Flag]Flag{}
+	c := cmd.clone(flags)
+	c.parent = nil
+
+	sc := &sourceCloner{flags: flags, sources: map[any]any{}}
+	for orig, fl := range flags {
+		if fl != orig {
+			sc.cloneChain(fl)
+		}
+	}
+	_ = c.Walk(func(sub *Command) error {
+		for _, arg := range sub.Arguments {
+			sc.cloneChain(arg)
+		}
+		return nil
+	})
+
+	return c
+}
+
+// clone copies cmd and its sub-commands, using flags to map each flag to
+// its copy so that a flag shared by several commands stays shared
+func (cmd *Command) clone(flags map[Flag]Flag) *Command {
+	c := *cmd
+
+	c.Aliases = slices.Clone(cmd.Aliases)
+	c.Authors = slices.Clone(cmd.Authors)
+	c.Metadata = maps.Clone(cmd.Metadata)
+	if cmd.StopOnNthArg != nil {
+		n := *cmd.StopOnNthArg
+		c.StopOnNthArg = &n
+	}
+
+	c.Flags = cloneFlags(cmd.Flags, flags)
+	if cmd.MutuallyExclusiveFlags != nil {
+		c.MutuallyExclusiveFlags = make([]MutuallyExclusiveFlags, len(cmd.MutuallyExclusiveFlags))
+		for i, grp := range cmd.MutuallyExclusiveFlags {
+			grp.Flags = slices.Clone(grp.Flags)
+			for j := range grp.Flags {
+				grp.Flags[j] = cloneFlags(grp.Flags[j], flags)
+			}
+			c.MutuallyExclusiveFlags[i] = grp
+		}
+	}
+	if cmd.helpFlag != nil {
+		c.helpFlag = cloneFlags([]Flag{cmd.helpFlag}, flags)[0]
+	}
+	if cmd.versionFlag != nil {
+		c.versionFlag = cloneFlags([]Flag{cmd.versionFlag}, flags)[0]
+	}
+
+	if cmd.Arguments != nil {
+		c.Arguments = make([]Argument, len(cmd.Arguments))
+		for i, arg := range cmd.Arguments {
+			if ca, ok := arg.(cloneable[Argument]); ok {
+				arg = ca.clone()
+			}
+			c.Arguments[i] = arg
+		}
+	}
+
+	if cmd.Commands != nil {
+		c.Commands = make([]*Command, len(cmd.Commands))
+		for i, sub := range cmd.Commands {
+			c.Commands[i] = sub.clone(flags)
+			c.Commands[i].parent = &c
+		}
+	}
+
+	c.appliedFlags = nil
+	c.setFlags = map[Flag]struct{}{}
+	c.parsedArgs = nil
+	c.isInError = false
+	c.shellCompletion = false
+	c.completionVersion = 0
+	c.completionCandidates = nil
+
+	// the categories refer to the sub-commands and flags of cmd
+	if cmd.categories != nil {
+		c.categories = newCommandCategories()
+		for _, sub := range c.Commands {
+			c.categories.AddCommand(sub.Category, sub)
+		}
+		sort.Sort(c.categories.(*commandCategories))
+	}
+	if cmd.flagCategories != nil {
+		c.flagCategories = newFlagCategoriesFromFlags(c.allFlags())
+	}
+
+	return &c
+}
+
+func cloneFlags(fls []Flag, flags map[Flag]Flag) []Flag {
+	if fls == nil {
+		return nil
+	}
+	cloned := make([]Flag, len(fls))
+	for i, fl := range fls {
+		if c, ok := flags[fl]; ok {
+			cloned[i] = c
+			continue
+		}
+		cloned[i] = fl
+		if cf, ok := fl.(cloneable[Flag]); ok {
+			cloned[i] = cf.clone()
+		}
+		flags[fl] = cloned[i]
+	}
+	return cloned
+}
+
+// cloneableSource is implemented by value sources which keep state for a
+// run, and are copied along with the flags and arguments reading them by
+// Command.Clone instead of being shared with the copy
+type cloneableSource interface {
+	cloneSource(sc *sourceCloner) any
+}
+
+// sourceCloner copies the stateful sources of cloned flags and arguments,
+// mapping each source to its copy so that a source shared by several flags
+// stays shared by their copies
+type sourceCloner struct {
+	flags   map[Flag]Flag
+	sources map[any]any
+}
+
+// clone returns the copy of src, or src itself if it keeps no state
+func (sc *sourceCloner) clone(src any) any {
+	cs, ok := src.(cloneableSource)
+	if !ok {
+		return src
+	}
+	if c, ok := sc.sources[src]; ok {
+		return c
+	}
+	c := cs.cloneSource(sc)
+	sc.sources[src] = c
+	return c
+}
+
+// cloneChain replaces the stateful sources of a cloned flag or argument by
+// their copies. The chain of the clone is its own, so it is updated in
+// place.
+func (sc *sourceCloner) cloneChain(v any) {
+	sf, ok := v.(sourcedFlags)
+	if !ok {
+		return
+	}
+	chain := sf.valueSources().Chain
+	for i, src := range chain {
+		if c := sc.clone(src); c != src {
+			chain[i] = c.(ValueSource)
+		}
+	}
+}
+
 // Count returns the num of occurrences of this flag
 func (cmd *Command) Count(name string) int {
 	if cf, ok := cmd.lookupFlag(name).(Countable); ok {
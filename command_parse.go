@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"unicode"
 )
@@ -22,11 +24,10 @@ func flagFromError(err error) (string, error) {
 	return trimmed, nil
 }
 
-func (cmd *Command) parseFlags(args Args) (Args, error) {
-	tracef("parsing flags from arguments %[1]q (cmd=%[2]q)", args, cmd.Name)
-
-	cmd.setFlags = map[Flag]struct{}{}
-	cmd.appliedFlags = cmd.allFlags()
+// applicableFlags returns the flags of the command along with the
+// persistent flags of its ancestors which are not shadowed by its own
+func (cmd *Command) applicableFlags() []Flag {
+	flags := cmd.allFlags()
 
 	tracef("walking command lineage for persistent flags (cmd=%[1]q)", cmd.Name)
 
@@ -67,10 +68,19 @@ func (cmd *Command) parseFlags(args Args) (Args, error) {
 			tracef("applying as persistent flag=%[1]q (cmd=%[2]q)", flNames, cmd.Name)
 
 			tracef("appending to applied flags flag=%[1]q (cmd=%[2]q)", flNames, cmd.Name)
-			cmd.appliedFlags = append(cmd.appliedFlags, fl)
+			flags = append(flags, fl)
 		}
 	}
 
+	return flags
+}
+
+func (cmd *Command) parseFlags(args Args) (Args, error) {
+	tracef("parsing flags from arguments %[1]q (cmd=%[2]q)", args, cmd.Name)
+
+	cmd.setFlags = map[Flag]struct{}{}
+	cmd.appliedFlags = cmd.applicableFlags()
+
 	tracef("parsing flags iteratively tail=%[1]q (cmd=%[2]q)", args.Tail(), cmd.Name)
 	defer tracef("done parsing flags (cmd=%[1]q)", cmd.Name)
 
@@ -249,3 +259,213 @@ func (cmd *Command) parseFlags(args Args) (Args, error) {
 	tracef("returning-2 (cmd=%[1]q) args %[2]q", cmd.Name, posArgs)
 	return &stringSliceArgs{posArgs}, nil
 }
+
+// ValueOrigin describes where the value of a flag came from
+type ValueOrigin int
+
+const (
+	// OriginDefault means the flag has not been set and holds its default value
+	OriginDefault ValueOrigin = iota
+	// OriginCommandLine means the flag has been given on the command line
+	OriginCommandLine
+	// OriginSource means the flag has been read from one of its sources
+	OriginSource
+)
+
+func (o ValueOrigin) String() string {
+	switch o {
+	case OriginCommandLine:
+		return "command line"
+	case OriginSource:
+		return "source"
+	default:
+		return "default"
+	}
+}
+
+// ValueProvenance describes where the value of a flag came from
+type ValueProvenance struct {
+	// Origin is where the value came from
+	Origin ValueOrigin
+	// Source is the source the value has been read from when Origin is
+	// OriginSource, nil otherwise
+	Source ValueSource
+}
+
+// String returns the source the value has been read from, such as
+// environment variable "PORT", or else the origin of the value
+func (p ValueProvenance) String() string {
+	if p.Origin == OriginSource && p.Source != nil {
+		return p.Source.String()
+	}
+	return p.Origin.String()
+}
+
+// flagProvenance returns where the value of fl came from
+func flagProvenance(fl Flag) ValueProvenance {
+	if sf, ok := fl.(sourcedFlag); ok && sf.valueSource() != nil {
+		return ValueProvenance{Origin: OriginSource, Source: sf.valueSource()}
+	}
+	if fl.IsSet() {
+		return ValueProvenance{Origin: OriginCommandLine}
+	}
+	return ValueProvenance{Origin: OriginDefault}
+}
+
+// ParsedFlag describes the final value of a flag and where it came from
+type ParsedFlag struct {
+	// Name is the name of the flag
+	Name string
+	// Flag is the flag itself
+	Flag Flag
+	// Command is the command defining the flag
+	Command *Command
+	// Value is the final value of the flag
+	Value any
+
+	ValueProvenance
+}
+
+// ParseResult is the outcome of Command.Parse
+type ParseResult struct {
+	// Command is the resolved leaf command which would have been run
+	Command *Command
+	// Flags holds the settings of the leaf command, see Command.Settings
+	Flags []ParsedFlag
+	// Args holds the positional arguments given to the leaf command,
+	// including the ones consumed by its Arguments
+	Args []string
+}
+
+// Flag returns the parsed flag with the given name, or nil if there is none
+func (r *ParseResult) Flag(name string) *ParsedFlag {
+	for i := range r.Flags {
+		if slices.Contains(r.Flags[i].Flag.Names(), name) {
+			return &r.Flags[i]
+		}
+	}
+	return nil
+}
+
+// sourcedFlag is implemented by flags which remember the source their
+// value has been read from
+type sourcedFlag interface {
+	valueSource() ValueSource
+}
+
+// Parse parses the arguments the same way Run does, resolving sub-commands
+// and the default command, and returns what Run would have acted on. Like
+// Run, it reads arguments from stdin and response files when enabled,
+// runs the ArgValidator and leaves the parsed values on the commands and
+// flags.
+//
+// Unlike Run, Parse doesn't run any Before, Action or After function nor
+// any flag action, and returns parsing errors instead of passing them to
+// OnUsageError or printing help. It doesn't handle shell completion nor
+// the version flag, and stops at the command asking for help, returning
+// it without showing help.
+func (cmd *Command) Parse(ctx context.Context, osArgs []string) (*ParseResult, error) {
+	if cmd.didSetupDefaults {
+		cmd.resetParseState()
+	}
+	leaf, args, err := cmd.parse(ctx, osArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParseResult{Command: leaf, Flags: leaf.Settings(), Args: args}, nil
+}
+
+// Settings returns every flag of cmd and its ancestors with its final
+// value and where the value came from, in order from the root. The
+// ancestor flags shadowed by a flag of the same name on a sub-command,
+// such as the help flag, are left out.
+func (cmd *Command) Settings() []ParsedFlag {
+	var settings []ParsedFlag
+	chain := commandChain(cmd)
+	seen := map[Flag]struct{}{}
+	for i, c := range chain {
+		for _, fl := range c.allFlags() {
+			if _, ok := seen[fl]; ok {
+				continue
+			}
+			seen[fl] = struct{}{}
+
+			if slices.ContainsFunc(chain[i+1:], func(sub *Command) bool {
+				sfl := sub.lFlag(fl.Names()[0])
+				return sfl != nil && sfl != fl
+			}) {
+				continue
+			}
+
+			settings = append(settings, ParsedFlag{
+				Name:            fl.Names()[0],
+				Flag:            fl,
+				Command:         c,
+				Value:           fl.Get(),
+				ValueProvenance: flagProvenance(fl),
+			})
+		}
+	}
+	return settings
+}
+
+// Provenance returns where the value of the flag with the given name came
+// from, which is OriginDefault if there is no such flag
+func (cmd *Command) Provenance(name string) ValueProvenance {
+	if fl := cmd.lookupFlag(name); fl != nil {
+		return flagProvenance(fl)
+	}
+	return ValueProvenance{}
+}
+
+// parse goes through the same parsing stages as run, without acting on
+// the result
+func (cmd *Command) parse(ctx context.Context, osArgs []string) (*Command, []string, error) {
+	tracef("dry-run parsing with arguments %[1]q (cmd=%[2]q)", osArgs, cmd.Name)
+
+	osArgs, err := cmd.prepareArgs(ctx, osArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, args, err := cmd.startParse(ctx, osArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.parseFlagArgs(args); err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.postParseFlags(); err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.checkMutuallyExclusiveFlags(); err != nil {
+		return nil, nil, err
+	}
+
+	if subCmd := cmd.resolveSubCommand(); subCmd != nil {
+		return subCmd.parse(ctx, cmd.Args().Slice())
+	}
+
+	posArgs := cmd.Args().Slice()
+	if cmd.checkHelp() {
+		return cmd, posArgs, nil
+	}
+
+	if validator := findArgValidator(cmd); validator != nil {
+		if err := validator(ctx, cmd); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := cmd.checkAllRequiredFlags(); err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.checkRequiredArguments(); err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.parseArguments(); err != nil {
+		return nil, nil, err
+	}
+
+	return cmd, posArgs, nil
+}
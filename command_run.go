@@ -1,86 +1,29 @@
 package cli
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"slices"
-	"unicode"
 )
 
 type helpShownKey struct{}
 
+// parseArgsFromStdin splits the input of the command into arguments with
+// SplitShellWords. The arguments following a "--" are ignored.
 func (cmd *Command) parseArgsFromStdin() ([]string, error) {
-	type state int
-	const (
-		stateSearchForToken  state = -1
-		stateSearchForString state = 0
-	)
-
-	st := stateSearchForToken
-	linenum := 1
-	token := ""
-	args := []string{}
-
-	breader := bufio.NewReader(cmd.Reader)
-
-outer:
-	for {
-		ch, _, err := breader.ReadRune()
-		if err == io.EOF {
-			switch st {
-			case stateSearchForToken:
-				if token != "--" {
-					args = append(args, token)
-				}
-			case stateSearchForString:
-				// make sure string is not empty
-				for _, t := range token {
-					if !unicode.IsSpace(t) {
-						args = append(args, token)
-					}
-				}
-			}
-			break outer
-		}
-		if err != nil {
-			return nil, err
-		}
-		switch st {
-		case stateSearchForToken:
-			if unicode.IsSpace(ch) || ch == '"' {
-				if ch == '\n' {
-					linenum++
-				}
-				if token != "" {
-					// end the processing here
-					if token == "--" {
-						break outer
-					}
-					args = append(args, token)
-					token = ""
-				}
-				if ch == '"' {
-					st = stateSearchForString
-				}
-				continue
-			}
-			token += string(ch)
-		case stateSearchForString:
-			if ch != '"' {
-				token += string(ch)
-			} else {
-				if token != "" {
-					args = append(args, token)
-					token = ""
-				}
-				/*else {
-					//TODO. Should we pass in empty strings ?
-				}*/
-				st = stateSearchForToken
-			}
-		}
+	data, err := io.ReadAll(cmd.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := SplitShellWords(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not read arguments from stdin: %w", err)
+	}
+	if i := slices.Index(args, "--"); i >= 0 {
+		args = args[:i]
 	}
 
 	tracef("parsed stdin args as %v (cmd=%[2]q)", args, cmd.Name)
@@ -91,18 +34,29 @@ outer:
 // Run is the entry point to the command graph. The positional
 // arguments are parsed according to the Flag and Command
 // definitions and the matching Action functions are run.
+//
+// A command may be run more than once. The state left by a previous
+// run, such as the values of flags and arguments, is reset first. The
+// exception is the Value of a GenericFlag or GenericArg: it is set in
+// place and can't be restored, so it keeps what a previous run set. Give
+// such flags and arguments a fresh Value before running the command again.
 func (cmd *Command) Run(ctx context.Context, osArgs []string) (deferErr error) {
+	if cmd.didSetupDefaults {
+		cmd.resetParseState()
+	}
 	_, deferErr = cmd.run(ctx, osArgs)
 	return deferErr
 }
 
-func (cmd *Command) run(ctx context.Context, osArgs []string) (_ context.Context, deferErr error) {
-	tracef("running with arguments %[1]q (cmd=%[2]q)", osArgs, cmd.Name)
+// prepareArgs sets cmd up to parse osArgs, in a run or in a dry run by
+// Parse, and returns the arguments to parse, which for the root command
+// include those read from stdin and response files
+func (cmd *Command) prepareArgs(ctx context.Context, osArgs []string) ([]string, error) {
 	cmd.setupDefaults(osArgs)
 
 	// Validate StopOnNthArg
 	if cmd.StopOnNthArg != nil && *cmd.StopOnNthArg < 0 {
-		return ctx, fmt.Errorf("StopOnNthArg must be non-negative, got %d", *cmd.StopOnNthArg)
+		return nil, fmt.Errorf("StopOnNthArg must be non-negative, got %d", *cmd.StopOnNthArg)
 	}
 
 	if v, ok := ctx.Value(commandContextKey).(*Command); ok {
@@ -112,32 +66,34 @@ func (cmd *Command) run(ctx context.Context, osArgs []string) (_ context.Context
 
 	if cmd.parent == nil {
 		if cmd.ReadArgsFromStdin {
-			if args, err := cmd.parseArgsFromStdin(); err != nil {
-				return ctx, err
-			} else {
-				osArgs = append(osArgs, args...)
+			args, err := cmd.parseArgsFromStdin()
+			if err != nil {
+				return nil, err
+			}
+			osArgs = append(osArgs, args...)
+		}
+		if cmd.ExpandResponseFiles {
+			var err error
+			if osArgs, err = expandResponseFiles(osArgs); err != nil {
+				return nil, err
 			}
 		}
-		// handle the completion flag separately from the flagset since
-		// completion could be attempted after a flag, but before its value was put
-		// on the command line. this causes the flagset to interpret the completion
-		// flag name as the value of the flag before it which is undesirable
-		// note that we can only do this because the shell autocomplete function
-		// always appends the completion flag at the end of the command
-		tracef("checking osArgs %v (cmd=%[2]q)", osArgs, cmd.Name)
-		cmd.shellCompletion, osArgs = checkShellCompleteFlag(cmd, osArgs)
-
-		tracef("setting cmd.shellCompletion=%[1]v from checkShellCompleteFlag (cmd=%[2]q)", cmd.shellCompletion && cmd.EnableShellCompletion, cmd.Name)
-		cmd.shellCompletion = cmd.EnableShellCompletion && cmd.shellCompletion
 	}
 
-	tracef("using post-checkShellCompleteFlag arguments %[1]q (cmd=%[2]q)", osArgs, cmd.Name)
+	return osArgs, nil
+}
 
+// startParse makes cmd the command of ctx, sets up the command graph and
+// the sources for a run of the root command, and prepares the flags of
+// cmd to be parsed. It returns the context of cmd and the arguments
+// following the command name.
+func (cmd *Command) startParse(ctx context.Context, osArgs []string) (context.Context, Args, error) {
 	tracef("setting self as cmd in context (cmd=%[1]q)", cmd.Name)
 	ctx = context.WithValue(ctx, commandContextKey, cmd)
 
 	if cmd.parent == nil {
 		cmd.setupCommandGraph()
+		cmd.startSourceRuns(ctx, osArgs)
 	}
 
 	var rargs Args = &stringSliceArgs{v: osArgs}
@@ -148,20 +104,91 @@ func (cmd *Command) run(ctx context.Context, osArgs []string) (_ context.Context
 			continue
 		}
 		if err := f.PreParse(); err != nil {
-			return ctx, err
+			return ctx, args, err
 		}
 	}
 
-	var err error
+	return ctx, args, nil
+}
 
+// parseFlagArgs parses the flags of cmd in args, unless it skips flag
+// parsing, and keeps the remaining arguments
+func (cmd *Command) parseFlagArgs(args Args) error {
 	if cmd.SkipFlagParsing {
 		tracef("skipping flag parsing (cmd=%[1]q)", cmd.Name)
 		cmd.parsedArgs = args
-	} else {
-		cmd.parsedArgs, err = cmd.parseFlags(args)
+		return nil
 	}
 
+	var err error
+	cmd.parsedArgs, err = cmd.parseFlags(args)
 	tracef("using post-parse arguments %[1]q (cmd=%[2]q)", args, cmd.Name)
+	return err
+}
+
+// checkMutuallyExclusiveFlags checks the mutually exclusive flag groups of
+// cmd and its ancestors, since persistent flags are inherited
+func (cmd *Command) checkMutuallyExclusiveFlags() error {
+	for pCmd := cmd; pCmd != nil; pCmd = pCmd.parent {
+		for _, grp := range pCmd.MutuallyExclusiveFlags {
+			if err := grp.check(cmd); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseArguments parses the positional arguments of cmd into its
+// Arguments, keeping the remaining ones
+func (cmd *Command) parseArguments() error {
+	if len(cmd.Arguments) == 0 {
+		return nil
+	}
+
+	rargs := cmd.Args().Slice()
+	tracef("calling argparse with %[1]v", rargs)
+	for _, arg := range cmd.Arguments {
+		var err error
+		if rargs, err = arg.Parse(rargs); err != nil {
+			tracef("calling with %[1]v (cmd=%[2]q)", err, cmd.Name)
+			return err
+		}
+	}
+	cmd.parsedArgs = &stringSliceArgs{v: rargs}
+	return nil
+}
+
+func (cmd *Command) run(ctx context.Context, osArgs []string) (_ context.Context, deferErr error) {
+	tracef("running with arguments %[1]q (cmd=%[2]q)", osArgs, cmd.Name)
+
+	osArgs, err := cmd.prepareArgs(ctx, osArgs)
+	if err != nil {
+		return ctx, err
+	}
+
+	if cmd.parent == nil {
+		// handle the completion flag separately from the flagset since
+		// completion could be attempted after a flag, but before its value was put
+		// on the command line. this causes the flagset to interpret the completion
+		// flag name as the value of the flag before it which is undesirable
+		// note that we can only do this because the shell autocomplete function
+		// always appends the completion flag at the end of the command
+		tracef("checking osArgs %v (cmd=%[2]q)", osArgs, cmd.Name)
+		cmd.shellCompletion, osArgs = checkShellCompleteFlag(cmd, osArgs)
+
+		tracef("setting cmd.shellCompletion=%[1]v from checkShellCompleteFlag (cmd=%[2]q)", cmd.shellCompletion && cmd.EnableShellCompletion, cmd.Name)
+		cmd.shellCompletion = cmd.EnableShellCompletion && cmd.shellCompletion
+	}
+
+	tracef("using post-checkShellCompleteFlag arguments %[1]q (cmd=%[2]q)", osArgs, cmd.Name)
+
+	ctx, args, err := cmd.startParse(ctx, osArgs)
+	if err != nil {
+		return ctx, err
+	}
+
+	err = cmd.parseFlagArgs(args)
 
 	if shouldRunCompletion(cmd) {
 		var beforeErr error
@@ -224,16 +251,12 @@ func (cmd *Command) run(ctx context.Context, osArgs []string) (_ context.Context
 		return ctx, nil
 	}
 
-	for _, flag := range cmd.allFlags() {
-		cmd.setMultiValueParsingConfig(flag)
-		isSet := flag.IsSet()
-		if err := flag.PostParse(); err != nil {
-			return ctx, err
-		}
-		// add env set flags here
-		if !isSet && flag.IsSet() {
-			cmd.setFlags[flag] = struct{}{}
+	if err := cmd.postParseFlags(); err != nil {
+		var vsErr *ValueSourceError
+		if errors.As(err, &vsErr) {
+			return cmd.handleRequiredError(ctx, err)
 		}
+		return ctx, err
 	}
 
 	if cmd.After != nil && !cmd.Root().shellCompletion {
@@ -253,62 +276,23 @@ func (cmd *Command) run(ctx context.Context, osArgs []string) (_ context.Context
 		}()
 	}
 
-	// Walk the parent chain to check mutually exclusive flag groups
-	// defined on ancestor commands, since persistent flags are inherited.
-	for pCmd := cmd; pCmd != nil; pCmd = pCmd.parent {
-		for _, grp := range pCmd.MutuallyExclusiveFlags {
-			if err := grp.check(cmd); err != nil {
-				if cmd.OnUsageError != nil {
-					err = cmd.OnUsageError(ctx, cmd, err, cmd.parent != nil)
-				} else {
-					fmt.Fprintf(cmd.Root().ErrWriter, "Incorrect Usage: %s\n\n", err.Error())
-					if cmd.parent == nil {
-						_ = ShowRootCommandHelp(cmd)
-					} else {
-						if err := ShowCommandHelp(ctx, cmd.parent, cmd.Name); err != nil {
-							_ = ShowSubcommandHelp(cmd)
-						}
-					}
+	if err := cmd.checkMutuallyExclusiveFlags(); err != nil {
+		if cmd.OnUsageError != nil {
+			err = cmd.OnUsageError(ctx, cmd, err, cmd.parent != nil)
+		} else {
+			fmt.Fprintf(cmd.Root().ErrWriter, "Incorrect Usage: %s\n\n", err.Error())
+			if cmd.parent == nil {
+				_ = ShowRootCommandHelp(cmd)
+			} else {
+				if err := ShowCommandHelp(ctx, cmd.parent, cmd.Name); err != nil {
+					_ = ShowSubcommandHelp(cmd)
 				}
-				return ctx, err
 			}
 		}
+		return ctx, err
 	}
 
-	var subCmd *Command
-	if cmd.parsedArgs.Present() {
-		tracef("checking positional args %[1]q (cmd=%[2]q)", cmd.parsedArgs, cmd.Name)
-
-		name := cmd.parsedArgs.First()
-
-		tracef("using first positional argument as sub-command name=%[1]q (cmd=%[2]q)", name, cmd.Name)
-
-		if cmd.SuggestCommandFunc != nil && name != "--" {
-			name = cmd.SuggestCommandFunc(cmd.Commands, name)
-			tracef("suggested command name=%1[q] (cmd=%[2]q)", name, cmd.Name)
-		}
-		subCmd = cmd.Command(name)
-		if subCmd == nil {
-			hasDefault := cmd.DefaultCommand != ""
-
-			if hasDefault {
-				tracef("using default command=%[1]q (cmd=%[2]q)", cmd.DefaultCommand, cmd.Name)
-			}
-
-			if hasDefault {
-				argsWithDefault := cmd.argsWithDefaultCommand(cmd.parsedArgs)
-				tracef("using default command args=%[1]q (cmd=%[2]q)", argsWithDefault, cmd.Name)
-				subCmd = cmd.Command(argsWithDefault.First())
-				cmd.parsedArgs = argsWithDefault
-			}
-		}
-	} else if cmd.DefaultCommand != "" {
-		tracef("no positional args present; checking default command %[1]q (cmd=%[2]q)", cmd.DefaultCommand, cmd.Name)
-
-		if dc := cmd.Command(cmd.DefaultCommand); dc != cmd {
-			subCmd = dc
-		}
-	}
+	subCmd := cmd.resolveSubCommand()
 
 	// If a subcommand has been resolved, let it handle the remaining execution.
 	if subCmd != nil {
@@ -362,25 +346,15 @@ func (cmd *Command) run(ctx context.Context, osArgs []string) (_ context.Context
 	}
 
 	// Run the command action.
-	if len(cmd.Arguments) > 0 {
-		rargs := cmd.Args().Slice()
-		tracef("calling argparse with %[1]v", rargs)
-		for _, arg := range cmd.Arguments {
-			var err error
-			rargs, err = arg.Parse(rargs)
-			if err != nil {
-				tracef("calling with %[1]v (cmd=%[2]q)", err, cmd.Name)
-				if _, ok := err.(*errRequiredArguments); ok {
-					return cmd.handleRequiredError(ctx, err)
-				}
-				if cmd.OnUsageError != nil {
-					err = cmd.OnUsageError(ctx, cmd, err, cmd.parent != nil)
-				}
-				err = cmd.handleExitCoder(ctx, err)
-				return ctx, err
-			}
+	if err := cmd.parseArguments(); err != nil {
+		if _, ok := err.(*errRequiredArguments); ok {
+			return cmd.handleRequiredError(ctx, err)
+		}
+		if cmd.OnUsageError != nil {
+			err = cmd.OnUsageError(ctx, cmd, err, cmd.parent != nil)
 		}
-		cmd.parsedArgs = &stringSliceArgs{v: rargs}
+		err = cmd.handleExitCoder(ctx, err)
+		return ctx, err
 	}
 
 	if err := cmd.Action(ctx, cmd); err != nil {
@@ -392,6 +366,66 @@ func (cmd *Command) run(ctx context.Context, osArgs []string) (_ context.Context
 	return ctx, deferErr
 }
 
+// postParseFlags populates the flags of the command which have not been
+// given on the command line from their sources
+func (cmd *Command) postParseFlags() error {
+	for _, flag := range cmd.allFlags() {
+		cmd.setMultiValueParsingConfig(flag)
+		isSet := flag.IsSet()
+		if err := flag.PostParse(); err != nil {
+			return err
+		}
+		// add env set flags here
+		if !isSet && flag.IsSet() {
+			cmd.setFlags[flag] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// resolveSubCommand returns the sub-command named by the first positional
+// argument, falling back to the default command, or nil if there is none.
+// The positional arguments are updated to include the name of the default
+// command when it is used.
+func (cmd *Command) resolveSubCommand() *Command {
+	var subCmd *Command
+	if cmd.parsedArgs.Present() {
+		tracef("checking positional args %[1]q (cmd=%[2]q)", cmd.parsedArgs, cmd.Name)
+
+		name := cmd.parsedArgs.First()
+
+		tracef("using first positional argument as sub-command name=%[1]q (cmd=%[2]q)", name, cmd.Name)
+
+		if cmd.SuggestCommandFunc != nil && name != "--" {
+			name = cmd.SuggestCommandFunc(cmd.Commands, name)
+			tracef("suggested command name=%1[q] (cmd=%[2]q)", name, cmd.Name)
+		}
+		subCmd = cmd.Command(name)
+		if subCmd == nil {
+			hasDefault := cmd.DefaultCommand != ""
+
+			if hasDefault {
+				tracef("using default command=%[1]q (cmd=%[2]q)", cmd.DefaultCommand, cmd.Name)
+			}
+
+			if hasDefault {
+				argsWithDefault := cmd.argsWithDefaultCommand(cmd.parsedArgs)
+				tracef("using default command args=%[1]q (cmd=%[2]q)", argsWithDefault, cmd.Name)
+				subCmd = cmd.Command(argsWithDefault.First())
+				cmd.parsedArgs = argsWithDefault
+			}
+		}
+	} else if cmd.DefaultCommand != "" {
+		tracef("no positional args present; checking default command %[1]q (cmd=%[2]q)", cmd.DefaultCommand, cmd.Name)
+
+		if dc := cmd.Command(cmd.DefaultCommand); dc != cmd {
+			subCmd = dc
+		}
+	}
+
+	return subCmd
+}
+
 func (cmd *Command) handleRequiredError(ctx context.Context, err error) (context.Context, error) {
 	cmd.isInError = true
 	if cmd.OnUsageError != nil {
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"flag"
 	"os"
 	"path/filepath"
@@ -166,6 +167,40 @@ func (cmd *Command) setupDefaults(osArgs []string) {
 	cmd.setFlags = map[Flag]struct{}{}
 }
 
+// resettable is implemented by flags and arguments which keep state from
+// parsing the arguments of a run
+type resettable interface {
+	reset()
+}
+
+// resetParseState restores cmd and its sub-commands, along with their
+// flags and arguments, to the state they had before being run so that
+// they can be run again. The one-time setup of defaults is kept.
+func (cmd *Command) resetParseState() {
+	tracef("resetting parse state (cmd=%[1]q)", cmd.Name)
+
+	_ = cmd.Walk(func(sub *Command) error {
+		sub.appliedFlags = nil
+		sub.setFlags = map[Flag]struct{}{}
+		sub.parsedArgs = nil
+		sub.isInError = false
+		sub.shellCompletion = false
+		sub.completionVersion = 0
+
+		for _, fl := range sub.allFlags() {
+			if r, ok := fl.(resettable); ok {
+				r.reset()
+			}
+		}
+		for _, arg := range sub.Arguments {
+			if r, ok := arg.(resettable); ok {
+				r.reset()
+			}
+		}
+		return nil
+	})
+}
+
 func (cmd *Command) setupCommandGraph() {
 	tracef("setting up command graph (cmd=%[1]q)", cmd.Name)
 
@@ -176,6 +211,108 @@ func (cmd *Command) setupCommandGraph() {
 		}
 		return nil
 	})
+
+	if cmd.EnvPrefix != "" {
+		cmd.setupEnvVars()
+	}
+}
+
+// sourcedFlags is implemented by flags and arguments which can read their
+// value from a chain of sources
+type sourcedFlags interface {
+	valueSources() ValueSourceChain
+}
+
+// runSource is implemented by value sources which keep state for the
+// duration of a run, such as cached values, and are told about the start
+// of every run along with its context and command line before any flag is
+// parsed
+type runSource interface {
+	startRun(ctx context.Context, args []string)
+}
+
+// startSourceRuns tells the sources of the flags and arguments of cmd and
+// its sub-commands which keep state for a run that a run starts with ctx
+// and args
+func (cmd *Command) startSourceRuns(ctx context.Context, args []string) {
+	start := func(v any) {
+		sf, ok := v.(sourcedFlags)
+		if !ok {
+			return
+		}
+		for _, src := range sf.valueSources().Chain {
+			if s, ok := src.(runSource); ok {
+				s.startRun(ctx, args)
+			}
+		}
+	}
+
+	_ = cmd.Walk(func(sub *Command) error {
+		for _, fl := range sub.allFlags() {
+			start(fl)
+		}
+		for _, arg := range sub.Arguments {
+			start(arg)
+		}
+		return nil
+	})
+}
+
+// envVarDeriver is implemented by flags which can read an environment
+// variable derived from the EnvPrefix of the root command
+type envVarDeriver interface {
+	deriveEnvVar(key string)
+}
+
+// setupEnvVars gives the flags of cmd and its sub-commands without an
+// environment variable one derived from the EnvPrefix
+func (cmd *Command) setupEnvVars() {
+	tracef("deriving environment variables from prefix %[1]q (cmd=%[2]q)", cmd.EnvPrefix, cmd.Name)
+
+	_ = cmd.Walk(func(sub *Command) error {
+		for _, fl := range sub.allFlags() {
+			if sub.isBuiltinFlag(fl) {
+				continue
+			}
+			if d, ok := fl.(envVarDeriver); ok {
+				d.deriveEnvVar(sub.envVarName(fl.Names()[0]))
+			}
+		}
+		return nil
+	})
+}
+
+// isBuiltinFlag reports whether fl is the help or version flag added to
+// cmd or one of its ancestors, rather than a user flag of the same name
+func (cmd *Command) isBuiltinFlag(fl Flag) bool {
+	for c := cmd; c != nil; c = c.parent {
+		if (c.helpFlag != nil && fl == c.helpFlag) || (c.versionFlag != nil && fl == c.versionFlag) {
+			return true
+		}
+	}
+	return false
+}
+
+// envVarName returns the name of the environment variable of the flag
+// with the given name on cmd, made of the EnvPrefix of the root command,
+// the path of cmd below the root and the name of the flag
+func (cmd *Command) envVarName(name string) string {
+	root := cmd.Root()
+	parts := []string{root.EnvPrefix}
+	if cmd != root {
+		parts = append(parts, cmd.Path()[len(root.Path()):]...)
+	}
+	parts = append(parts, name)
+
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.Join(parts, "_"))
 }
 
 func (cmd *Command) setupSubcommand() {
@@ -263,6 +400,7 @@ func (cmd *Command) ensureHelp() {
 
 				tracef("appending HelpFlag (cmd=%[1]q)", cmd.Name)
 				cmd.appendFlag(localHelpFlag)
+				cmd.helpFlag = localHelpFlag
 				cmd.globaHelpFlagAdded = true
 			} else {
 				tracef("HelpFlag already added, skip (cmd=%[1]q)", cmd.Name)
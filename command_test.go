@@ -10,6 +10,8 @@ import (
 	"io"
 	"net/mail"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -4913,10 +4915,8 @@ func TestCommandReadArgsFromStdIn(t *testing.T) {
 			input: `
 			"
 			`,
-			args:          []string{"foo"},
-			expectedInt:   0,
-			expectedFloat: 0,
-			expectedSlice: []string{},
+			args:        []string{"foo"},
+			expectError: true,
 		},
 		{
 			name: "invalid string2",
@@ -4934,8 +4934,19 @@ func TestCommandReadArgsFromStdIn(t *testing.T) {
 			"
 			hello
 			`,
+			args:        []string{"foo"},
+			expectError: true,
+		},
+		{
+			name: "shell words",
+			input: `
+			# flags for the command
+			--if 100 \
+			--ssf 'it''s' --ssf "say \"hi\"" --ssf ""
+			`,
 			args:          []string{"foo"},
-			expectedSlice: []string{"hello"},
+			expectedInt:   100,
+			expectedSlice: []string{"its", `say "hi"`, ""},
 		},
 	}
 
@@ -4988,6 +4999,139 @@ func TestCommandReadArgsFromStdIn(t *testing.T) {
 	}
 }
 
+func TestCommandExpandResponseFiles(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		args         []string
+		disabled     bool
+		expectedTags []string
+		expectedArgs []string
+		expectedErr  string
+	}{
+		{
+			name: "expanded",
+			files: map[string]string{
+				"tags.rsp": "# tags\n--tag 'a b' \\\n--tag \"c\"\n",
+			},
+			args:         []string{"foo", "--tag", "x", "@{dir}/tags.rsp", "pos"},
+			expectedTags: []string{"x", "a b", "c"},
+			expectedArgs: []string{"pos"},
+		},
+		{
+			name: "nested",
+			files: map[string]string{
+				"outer.rsp": "--tag a @{dir}/inner.rsp --tag c",
+				"inner.rsp": "--tag b",
+			},
+			args:         []string{"foo", "@{dir}/outer.rsp"},
+			expectedTags: []string{"a", "b", "c"},
+			expectedArgs: []string{},
+		},
+		{
+			name: "escaped",
+			files: map[string]string{
+				"args.rsp": "@@{dir}/args.rsp",
+			},
+			args:         []string{"foo", "@@{dir}/args.rsp", "@", "@{dir}/args.rsp"},
+			expectedTags: []string{},
+			expectedArgs: []string{"@{dir}/args.rsp", "@", "@{dir}/args.rsp"},
+		},
+		{
+			name: "after terminator",
+			files: map[string]string{
+				"args.rsp": "pos -- @{dir}/args.rsp",
+			},
+			args:         []string{"foo", "@{dir}/args.rsp", "@{dir}/args.rsp", "@@x"},
+			expectedTags: []string{},
+			expectedArgs: []string{"pos", "@{dir}/args.rsp", "@{dir}/args.rsp", "@@x"},
+		},
+		{
+			name: "disabled",
+			files: map[string]string{
+				"args.rsp": "--tag a",
+			},
+			args:         []string{"foo", "@{dir}/args.rsp"},
+			disabled:     true,
+			expectedTags: []string{},
+			expectedArgs: []string{"@{dir}/args.rsp"},
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"a.rsp": "--tag a @{dir}/b.rsp",
+				"b.rsp": "--tag b\n\n  @{dir}/a.rsp",
+			},
+			args:        []string{"foo", "@{dir}/a.rsp"},
+			expectedErr: `response file "{dir}/b.rsp": line 3: response file "{dir}/a.rsp" includes itself`,
+		},
+		{
+			name:        "missing file",
+			args:        []string{"foo", "@{dir}/missing.rsp"},
+			expectedErr: `response file "{dir}/missing.rsp": open {dir}/missing.rsp: `,
+		},
+		{
+			name: "missing nested file",
+			files: map[string]string{
+				"args.rsp": "--tag a\n@{dir}/missing.rsp",
+			},
+			args:        []string{"foo", "@{dir}/args.rsp"},
+			expectedErr: `response file "{dir}/args.rsp": line 2: could not read response file "{dir}/missing.rsp": `,
+		},
+		{
+			name: "syntax error",
+			files: map[string]string{
+				"args.rsp": "--tag a\n--tag 'b",
+			},
+			args:        []string{"foo", "@{dir}/args.rsp"},
+			expectedErr: `response file "{dir}/args.rsp": line 2, column 7: unterminated single-quoted string`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := filepath.ToSlash(t.TempDir())
+			expand := func(s string) string {
+				return strings.ReplaceAll(s, "{dir}", dir)
+			}
+			for name, content := range test.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(expand(content)), 0o644))
+			}
+			args := make([]string, len(test.args))
+			for i, arg := range test.args {
+				args[i] = expand(arg)
+			}
+
+			var gotArgs []string
+			cmd := &Command{
+				Name:                "foo",
+				ExpandResponseFiles: !test.disabled,
+				Flags: []Flag{
+					&StringSliceFlag{Name: "tag"},
+				},
+				Action: func(_ context.Context, cmd *Command) error {
+					gotArgs = cmd.Args().Slice()
+					return nil
+				},
+			}
+
+			err := cmd.Run(buildTestContext(t), args)
+			if test.expectedErr != "" {
+				require.ErrorContains(t, err, expand(test.expectedErr))
+				var rfErr *ResponseFileError
+				require.ErrorAs(t, err, &rfErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedTags, cmd.StringSlice("tag"))
+			for i, arg := range test.expectedArgs {
+				test.expectedArgs[i] = expand(arg)
+			}
+			assert.Equal(t, test.expectedArgs, gotArgs)
+		})
+	}
+}
+
 func TestZeroValueCommand(t *testing.T) {
 	var cmd Command
 	assert.NoError(t, cmd.Run(context.Background(), []string{"foo"}))
@@ -5396,6 +5540,7 @@ func TestJSONExportCommand(t *testing.T) {
 					"hidden": false,
 					"hideDefault": false,
 					"local": false,
+					"mergeSources": false,
 					"defaultValue": "",
 					"aliases": [
 					  "sub-fl",
@@ -5406,6 +5551,7 @@ func TestJSONExportCommand(t *testing.T) {
 					  "TrimSpace": false
 					},
 					"onlyOnce": false,
+					"noEnvPrefix": false,
 					"validateDefaults" : false
 				  },
 				  {
@@ -5417,6 +5563,7 @@ func TestJSONExportCommand(t *testing.T) {
 					"hidden": false,
 					"hideDefault": false,
 					"local": false,
+					"mergeSources": false,
 					"defaultValue": false,
 					"aliases": [
 					  "s"
@@ -5426,6 +5573,7 @@ func TestJSONExportCommand(t *testing.T) {
 					  "Count": null
 					},
 					"onlyOnce": false,
+					"noEnvPrefix": false,
 					"validateDefaults" : false
 				  }
 				],
@@ -5438,6 +5586,7 @@ func TestJSONExportCommand(t *testing.T) {
 				"metadata": null,
 				"sliceFlagSeparator": "",
 				"disableSliceFlagSeparator": false,
+				"envPrefix": "",
 				"mapFlagKeyValueSeparator": "",
 				"useShortOptionHandling": false,
 				"suggest": false,
@@ -5447,6 +5596,7 @@ func TestJSONExportCommand(t *testing.T) {
 				"mutuallyExclusiveFlags": null,
 				"arguments": null,
 				"readArgsFromStdin": false,
+				"expandResponseFiles": false,
 				"stopOnNthArg": null
 			  }
 			],
@@ -5460,6 +5610,7 @@ func TestJSONExportCommand(t *testing.T) {
 				"hidden": false,
 				"hideDefault": false,
 				"local": false,
+				"mergeSources": false,
 				"defaultValue": "",
 				"aliases": [
 				  "fl",
@@ -5470,6 +5621,7 @@ func TestJSONExportCommand(t *testing.T) {
 				  "TrimSpace": false
 				},
 				"onlyOnce": false,
+				"noEnvPrefix": false,
 				"validateDefaults" : false
 			  },
 			  {
@@ -5481,6 +5633,7 @@ func TestJSONExportCommand(t *testing.T) {
 				"hidden": false,
 				"hideDefault": false,
 				"local": false,
+				"mergeSources": false,
 				"defaultValue": false,
 				"aliases": [
 				  "b"
@@ -5490,6 +5643,7 @@ func TestJSONExportCommand(t *testing.T) {
 				  "Count": null
 				},
 				"onlyOnce": false,
+				"noEnvPrefix": false,
 				"validateDefaults" : false
 			  }
 			],
@@ -5502,6 +5656,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"metadata": null,
 			"sliceFlagSeparator": "",
 			"disableSliceFlagSeparator": false,
+			"envPrefix": "",
 			"mapFlagKeyValueSeparator": "",
 			"useShortOptionHandling": false,
 			"suggest": false,
@@ -5511,6 +5666,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"mutuallyExclusiveFlags": null,
 			"arguments": null,
 			"readArgsFromStdin": false,
+			"expandResponseFiles": false,
 			"stopOnNthArg": null
 		  },
 		  {
@@ -5537,6 +5693,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"metadata": null,
 			"sliceFlagSeparator": "",
 			"disableSliceFlagSeparator": false,
+			"envPrefix": "",
 			"mapFlagKeyValueSeparator": "",
 			"useShortOptionHandling": false,
 			"suggest": false,
@@ -5546,6 +5703,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"mutuallyExclusiveFlags": null,
 			"arguments": null,
 			"readArgsFromStdin": false,
+			"expandResponseFiles": false,
 			"stopOnNthArg": null
 		  },
 		  {
@@ -5569,6 +5727,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"metadata": null,
 			"sliceFlagSeparator": "",
 			"disableSliceFlagSeparator": false,
+			"envPrefix": "",
 			"mapFlagKeyValueSeparator": "",
 			"useShortOptionHandling": false,
 			"suggest": false,
@@ -5578,6 +5737,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"mutuallyExclusiveFlags": null,
 			"arguments": null,
 			"readArgsFromStdin": false,
+			"expandResponseFiles": false,
 			"stopOnNthArg": null
 		  },
 		  {
@@ -5601,6 +5761,7 @@ func TestJSONExportCommand(t *testing.T) {
 				"hidden": false,
 				"hideDefault": false,
 				"local": false,
+				"mergeSources": false,
 				"defaultValue": false,
 				"aliases": null,
 				"takesFileArg": false,
@@ -5608,6 +5769,7 @@ func TestJSONExportCommand(t *testing.T) {
 				  "Count": null
 				},
 				"onlyOnce": false,
+				"noEnvPrefix": false,
 				"validateDefaults": false
 			  }
 			],
@@ -5620,6 +5782,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"metadata": null,
 			"sliceFlagSeparator": "",
 			"disableSliceFlagSeparator": false,
+			"envPrefix": "",
 			"mapFlagKeyValueSeparator": "",
 			"useShortOptionHandling": false,
 			"suggest": false,
@@ -5629,6 +5792,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"mutuallyExclusiveFlags": null,
 			"arguments": null,
 			"readArgsFromStdin": false,
+			"expandResponseFiles": false,
 			"stopOnNthArg": null
 		  },
 		  {
@@ -5667,6 +5831,7 @@ func TestJSONExportCommand(t *testing.T) {
 					"hidden": false,
 					"hideDefault": false,
 					"local": false,
+					"mergeSources": false,
 					"defaultValue": false,
 					"aliases": [
 					  "s"
@@ -5676,6 +5841,7 @@ func TestJSONExportCommand(t *testing.T) {
 					  "Count": null
 					},
 					"onlyOnce": false,
+					"noEnvPrefix": false,
 					"validateDefaults" : false
 				  }
 				],
@@ -5688,6 +5854,7 @@ func TestJSONExportCommand(t *testing.T) {
 				"metadata": null,
 				"sliceFlagSeparator": "",
 				"disableSliceFlagSeparator": false,
+				"envPrefix": "",
 				"mapFlagKeyValueSeparator": "",
 				"useShortOptionHandling": false,
 				"suggest": false,
@@ -5697,6 +5864,7 @@ func TestJSONExportCommand(t *testing.T) {
 				"mutuallyExclusiveFlags": null,
 				"arguments": null,
 				"readArgsFromStdin": false,
+				"expandResponseFiles": false,
 				"stopOnNthArg": null
 			  }
 			],
@@ -5710,6 +5878,7 @@ func TestJSONExportCommand(t *testing.T) {
 				"hidden": false,
 				"hideDefault": false,
 				"local": false,
+				"mergeSources": false,
 				"defaultValue": "",
 				"aliases": [
 				  "fl",
@@ -5720,6 +5889,7 @@ func TestJSONExportCommand(t *testing.T) {
 				  "TrimSpace": false
 				},
 				"onlyOnce": false,
+				"noEnvPrefix": false,
 				"validateDefaults" : false
 			  },
 			  {
@@ -5731,6 +5901,7 @@ func TestJSONExportCommand(t *testing.T) {
 				"hidden": false,
 				"hideDefault": false,
 				"local": false,
+				"mergeSources": false,
 				"defaultValue": false,
 				"aliases": [
 				  "b"
@@ -5740,6 +5911,7 @@ func TestJSONExportCommand(t *testing.T) {
 				  "Count": null
 				},
 				"onlyOnce": false,
+				"noEnvPrefix": false,
 				"validateDefaults" : false
 			  }
 			],
@@ -5752,6 +5924,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"metadata": null,
 			"sliceFlagSeparator": "",
 			"disableSliceFlagSeparator": false,
+			"envPrefix": "",
 			"mapFlagKeyValueSeparator": "",
 			"useShortOptionHandling": false,
 			"suggest": false,
@@ -5761,6 +5934,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"mutuallyExclusiveFlags": null,
 			"arguments": null,
 			"readArgsFromStdin": false,
+			"expandResponseFiles": false,
 			"stopOnNthArg": null
 		  }
 		],
@@ -5774,6 +5948,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"hidden": false,
 			"hideDefault": false,
 			"local": false,
+			"mergeSources": false,
 			"defaultValue": "value",
 			"aliases": [
 			  "s"
@@ -5783,6 +5958,7 @@ func TestJSONExportCommand(t *testing.T) {
 			  "TrimSpace": false
 			},
 			"onlyOnce": false,
+			"noEnvPrefix": false,
 			"validateDefaults" : false
 		  },
 		  {
@@ -5794,6 +5970,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"hidden": false,
 			"hideDefault": false,
 			"local": false,
+			"mergeSources": false,
 			"defaultValue": "",
 			"aliases": [
 			  "fl",
@@ -5804,6 +5981,7 @@ func TestJSONExportCommand(t *testing.T) {
 			  "TrimSpace": false
 			},
 			"onlyOnce": false,
+			"noEnvPrefix": false,
 			"validateDefaults" : false
 		  },
 		  {
@@ -5815,6 +5993,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"hidden": false,
 			"hideDefault": false,
 			"local": false,
+			"mergeSources": false,
 			"defaultValue": false,
 			"aliases": [
 			  "b"
@@ -5824,6 +6003,7 @@ func TestJSONExportCommand(t *testing.T) {
 			  "Count": null
 			},
 			"onlyOnce": false,
+			"noEnvPrefix": false,
 			"validateDefaults" : false
 		  },
 		  {
@@ -5835,6 +6015,7 @@ func TestJSONExportCommand(t *testing.T) {
 			"hidden": true,
 			"hideDefault": false,
 			"local": false,
+			"mergeSources": false,
 			"defaultValue": false,
 			"aliases": null,
 			"takesFileArg": false,
@@ -5842,6 +6023,7 @@ func TestJSONExportCommand(t *testing.T) {
 			  "Count": null
 			},
 			"onlyOnce": false,
+			"noEnvPrefix": false,
 			"validateDefaults" : false
 		  }
 		],
@@ -5860,6 +6042,7 @@ func TestJSONExportCommand(t *testing.T) {
 		"metadata": null,
 		"sliceFlagSeparator": "",
 		"disableSliceFlagSeparator": false,
+		"envPrefix": "",
 		"mapFlagKeyValueSeparator": "",
 		"useShortOptionHandling": false,
 		"suggest": false,
@@ -5872,14 +6055,19 @@ func TestJSONExportCommand(t *testing.T) {
 			"name": "fooi",
 			"value": 0,
 			"usageText": "",
+			"description": "",
+			"defaultText": "",
+			"hideDefault": false,
 			"minTimes": 0,
 			"maxTimes": 0,
 			"config": {
 			  "Base": 0
-			}
+			},
+			"takesFileArg": false
 		  }
 		],
 		"readArgsFromStdin": false,
+		"expandResponseFiles": false,
 		"stopOnNthArg": null
 	  }
 `
@@ -6596,3 +6784,733 @@ func TestRunWithNoOsArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandParse(t *testing.T) {
+	t.Setenv("TEST_PARSE_REGION", "eu-west")
+
+	var ran []string
+	record := func(name string) ActionFunc {
+		return func(context.Context, *Command) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+
+	cmd := &Command{
+		Name: "app",
+		Flags: []Flag{
+			&BoolFlag{Name: "verbose", Aliases: []string{"V"}},
+			&StringFlag{Name: "log", Local: true},
+		},
+		Before: func(ctx context.Context, _ *Command) (context.Context, error) {
+			ran = append(ran, "before")
+			return ctx, nil
+		},
+		After: func(context.Context, *Command) error {
+			ran = append(ran, "after")
+			return nil
+		},
+		Commands: []*Command{
+			{
+				Name: "deploy",
+				Flags: []Flag{
+					&StringFlag{
+						Name:    "region",
+						Sources: EnvVars("TEST_PARSE_REGION"),
+					},
+					&IntFlag{
+						Name:   "replicas",
+						Value:  1,
+						Action: func(context.Context, *Command, int) error { ran = append(ran, "flag action"); return nil },
+					},
+					&StringFlag{Name: "tag"},
+				},
+				Arguments: []Argument{
+					&StringArg{Name: "service"},
+				},
+				Action: record("deploy"),
+			},
+		},
+		DefaultCommand: "deploy",
+		Action:         record("app"),
+	}
+
+	result, err := cmd.Parse(buildTestContext(t), []string{"app", "-V", "--log", "debug", "deploy", "--replicas", "3", "api", "extra"})
+	require.NoError(t, err)
+	assert.Empty(t, ran)
+
+	require.NotNil(t, result)
+	assert.Equal(t, "deploy", result.Command.Name)
+	assert.Equal(t, []string{"api", "extra"}, result.Args)
+	assert.Equal(t, "api", result.Command.StringArg("service"))
+
+	var names []string
+	for _, fl := range result.Flags {
+		names = append(names, fl.Name)
+	}
+	assert.Equal(t, []string{"verbose", "log", "region", "replicas", "tag", "help"}, names)
+
+	verbose := result.Flag("V")
+	require.NotNil(t, verbose)
+	assert.Equal(t, true, verbose.Value)
+	assert.Equal(t, OriginCommandLine, verbose.Origin)
+	assert.Equal(t, cmd, verbose.Command)
+
+	region := result.Flag("region")
+	require.NotNil(t, region)
+	assert.Equal(t, "eu-west", region.Value)
+	assert.Equal(t, OriginSource, region.Origin)
+	assert.Equal(t, `environment variable "TEST_PARSE_REGION"`, region.Source.String())
+
+	replicas := result.Flag("replicas")
+	require.NotNil(t, replicas)
+	assert.Equal(t, 3, replicas.Value)
+	assert.Equal(t, OriginCommandLine, replicas.Origin)
+
+	tag := result.Flag("tag")
+	require.NotNil(t, tag)
+	assert.Equal(t, "", tag.Value)
+	assert.Equal(t, OriginDefault, tag.Origin)
+	assert.Nil(t, tag.Source)
+
+	assert.Nil(t, result.Flag("nope"))
+}
+
+func TestCommandParseDefaultCommand(t *testing.T) {
+	cmd := &Command{
+		Name:           "app",
+		DefaultCommand: "serve",
+		Commands: []*Command{
+			{Name: "serve", Action: func(context.Context, *Command) error { return errors.New("should not run") }},
+		},
+	}
+
+	result, err := cmd.Parse(buildTestContext(t), []string{"app", "index.html"})
+	require.NoError(t, err)
+	assert.Equal(t, "serve", result.Command.Name)
+	assert.Equal(t, []string{"index.html"}, result.Args)
+}
+
+func TestCommandParseArgsFromStdin(t *testing.T) {
+	cmd := &Command{
+		Name:              "app",
+		ReadArgsFromStdin: true,
+		Reader:            strings.NewReader("--name bob extra"),
+		Flags:             []Flag{&StringFlag{Name: "name"}},
+	}
+
+	result, err := cmd.Parse(buildTestContext(t), []string{"app"})
+	require.NoError(t, err)
+	assert.Equal(t, "bob", cmd.String("name"))
+	assert.Equal(t, []string{"extra"}, result.Args)
+}
+
+func TestCommandParseErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		cmd         *Command
+		args        []string
+		expectedErr string
+	}{
+		{
+			name:        "undefined flag",
+			cmd:         &Command{Name: "app"},
+			args:        []string{"app", "--nope"},
+			expectedErr: "flag provided but not defined: -nope",
+		},
+		{
+			name: "missing required flag",
+			cmd: &Command{
+				Name:  "app",
+				Flags: []Flag{&StringFlag{Name: "name", Required: true}},
+			},
+			args:        []string{"app"},
+			expectedErr: `Required flag "name" not set`,
+		},
+		{
+			name: "mutually exclusive flags",
+			cmd: &Command{
+				Name: "app",
+				MutuallyExclusiveFlags: []MutuallyExclusiveFlags{
+					{Flags: [][]Flag{{&BoolFlag{Name: "a"}}, {&BoolFlag{Name: "b"}}}},
+				},
+			},
+			args:        []string{"app", "-a", "-b"},
+			expectedErr: "option a cannot be set along with option b",
+		},
+		{
+			name: "argument validator",
+			cmd: &Command{
+				Name: "app",
+				ArgValidator: func(_ context.Context, cmd *Command) error {
+					return fmt.Errorf("unexpected arguments %q", cmd.Args().Slice())
+				},
+				Commands: []*Command{{Name: "sub"}},
+			},
+			args:        []string{"app", "sub", "x"},
+			expectedErr: `unexpected arguments ["x"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.cmd.Parse(buildTestContext(t), tt.args)
+			assert.Nil(t, result)
+			require.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestCommandRunTwice(t *testing.T) {
+	var (
+		token   string
+		verbose int
+		files   []string
+	)
+
+	cmd := &Command{
+		Name: "app",
+		Flags: []Flag{
+			&StringFlag{Name: "token", OnlyOnce: true, Destination: &token},
+			&BoolFlag{Name: "verbose", Aliases: []string{"v"}},
+			&BoolWithInverseFlag{Name: "color", OnlyOnce: true},
+			&StringFlag{Name: "region", Value: "us-east", Sources: EnvVars("TEST_RUN_TWICE_REGION")},
+		},
+		Commands: []*Command{
+			{
+				Name: "copy",
+				Arguments: []Argument{
+					&StringArg{Name: "src"},
+					&StringArgs{Name: "dst", Min: 0, Max: -1, Destination: &files},
+				},
+				Action: func(_ context.Context, cmd *Command) error {
+					verbose = cmd.Count("verbose")
+					return nil
+				},
+			},
+		},
+	}
+
+	t.Setenv("TEST_RUN_TWICE_REGION", "eu-west")
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app", "--token", "abc", "-v", "-v", "--no-color", "copy", "a", "b", "c"}))
+	assert.Equal(t, "abc", token)
+	assert.Equal(t, 2, verbose)
+	assert.False(t, cmd.Bool("color"))
+	assert.Equal(t, "eu-west", cmd.String("region"))
+	assert.Equal(t, "a", cmd.Command("copy").StringArg("src"))
+	assert.Equal(t, []string{"b", "c"}, files)
+
+	require.NoError(t, os.Unsetenv("TEST_RUN_TWICE_REGION"))
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app", "--token", "def", "--color", "-v", "copy", "x"}))
+	assert.Equal(t, "def", token)
+	assert.Equal(t, 1, verbose)
+	assert.True(t, cmd.Bool("color"))
+	assert.Equal(t, "us-east", cmd.String("region"))
+	assert.False(t, cmd.IsSet("region"))
+	assert.Equal(t, "x", cmd.Command("copy").StringArg("src"))
+	assert.Empty(t, files)
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app", "copy"}))
+	assert.Equal(t, "", token)
+	assert.False(t, cmd.IsSet("token"))
+	assert.Equal(t, 0, verbose)
+	assert.Equal(t, "", cmd.Command("copy").StringArg("src"))
+}
+
+func TestCommandClone(t *testing.T) {
+	verbose := &BoolFlag{Name: "verbose", Aliases: []string{"v"}}
+	cmd := &Command{
+		Name:  "app",
+		Flags: []Flag{verbose},
+		Commands: []*Command{
+			{
+				Name:    "greet",
+				Aliases: []string{"g"},
+				Flags: []Flag{
+					&StringFlag{Name: "greeting", Value: "hello"},
+					&BoolWithInverseFlag{Name: "shout"},
+				},
+				MutuallyExclusiveFlags: []MutuallyExclusiveFlags{
+					{Flags: [][]Flag{{&BoolFlag{Name: "json"}}, {&BoolFlag{Name: "yaml"}}}},
+				},
+				Arguments: []Argument{
+					&StringArg{Name: "name"},
+				},
+				Action: func(context.Context, *Command) error { return nil },
+			},
+		},
+	}
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app", "-v", "greet", "--greeting", "hi", "--json", "bob"}))
+
+	clone := cmd.Clone()
+	require.NotSame(t, cmd, clone)
+	assert.Nil(t, clone.parent)
+
+	greet := clone.Command("greet")
+	require.NotNil(t, greet)
+	require.NotSame(t, cmd.Command("greet"), greet)
+	assert.Same(t, clone, greet.parent)
+	assert.NotSame(t, verbose, clone.Flags[0])
+	assert.NotSame(t, cmd.Command("greet").MutuallyExclusiveFlags[0].Flags[0][0], greet.MutuallyExclusiveFlags[0].Flags[0][0])
+	assert.NotSame(t, cmd.Command("greet").Arguments[0], greet.Arguments[0])
+
+	// the copy carries none of the state of the previous run
+	assert.False(t, clone.Flags[0].IsSet())
+	assert.Equal(t, "", greet.StringArg("name"))
+
+	require.NoError(t, clone.Run(buildTestContext(t), []string{"app", "greet", "--yaml", "--no-shout", "alice"}))
+	assert.False(t, clone.Bool("verbose"))
+	assert.Equal(t, "hello", greet.String("greeting"))
+	assert.True(t, greet.Bool("yaml"))
+	assert.Equal(t, "alice", greet.StringArg("name"))
+
+	// while the original is left untouched
+	assert.True(t, cmd.Bool("verbose"))
+	assert.Equal(t, "hi", cmd.Command("greet").String("greeting"))
+	assert.Equal(t, "bob", cmd.Command("greet").StringArg("name"))
+}
+
+func TestCommandCloneParallel(t *testing.T) {
+	cfg := NewJSONConfig("")
+	cmd := &Command{
+		Name: "app",
+		Flags: []Flag{
+			cfg.ConfigFlag(),
+			&IntFlag{Name: "count", OnlyOnce: true},
+			&StringSliceFlag{Name: "tag"},
+			&StringFlag{Name: "name", Sources: NewValueSourceChain(cfg.Key("name"))},
+		},
+		Commands: []*Command{
+			{
+				Name: "echo",
+				Arguments: []Argument{
+					&StringArgs{Name: "words", Min: 1, Max: -1},
+				},
+				Action: func(_ context.Context, cmd *Command) error {
+					_, err := fmt.Fprintf(cmd.Root().Writer, "%d %v %v %s",
+						cmd.Int("count"), cmd.StringSlice("tag"), cmd.StringArgs("words"), cmd.String("name"))
+					return err
+				},
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		t.Run(fmt.Sprintf("run-%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			clone := cmd.Clone()
+			clone.Writer = out
+
+			n := strconv.Itoa(i)
+			path := filepath.Join(dir, n+".json")
+			require.NoError(t, os.WriteFile(path, []byte(`{"name": "name-`+n+`"}`), 0o644))
+
+			require.NoError(t, clone.Run(buildTestContext(t), []string{"app", "--config", path, "--count", n, "--tag", n, "echo", "say", n}))
+			assert.Equal(t, fmt.Sprintf("%[1]d [%[1]d] [say %[1]d] name-%[1]d", i), out.String())
+		})
+	}
+}
+
+func TestCommandValidate(t *testing.T) {
+	negative := -1
+	errEmpty := errors.New("must not be empty")
+	notEmpty := func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return errEmpty
+		}
+		return nil
+	}
+
+	cmd := &Command{
+		Name:           "app",
+		DefaultCommand: "serve",
+		Flags: []Flag{
+			&StringFlag{Name: "config", Aliases: []string{"c"}},
+			&BoolFlag{Name: "color", Aliases: []string{"c"}},
+			&StringFlag{Name: "user", Validator: notEmpty, ValidateDefaults: true},
+			&StringFlag{Name: "nick", Validator: notEmpty},
+			&BoolWithInverseFlag{Name: "cache", Validator: func(bool) error { return errEmpty }},
+			&StringFlag{Name: "token", Validator: notEmpty, Required: true},
+			&StringFlag{Name: "host", Value: "localhost", Validator: notEmpty},
+		},
+		Commands: []*Command{
+			{
+				Name:    "deploy",
+				Aliases: []string{"d"},
+				Arguments: []Argument{
+					&StringArg{Name: "service", Value: " ", Validator: notEmpty},
+					&StringArg{Name: "zone", Validator: notEmpty},
+					&StringArg{Name: "region", Validator: notEmpty, Required: true},
+					&StringArgs{Name: "hosts", Min: 2, Max: 1},
+				},
+			},
+			{
+				Name:         "delete",
+				Aliases:      []string{"d"},
+				StopOnNthArg: &negative,
+				MutuallyExclusiveFlags: []MutuallyExclusiveFlags{
+					{Flags: [][]Flag{{&BoolFlag{Name: "force"}}, {&BoolFlag{Name: "force"}}}},
+				},
+			},
+		},
+	}
+
+	err := cmd.Validate()
+	require.Error(t, err)
+
+	var defErrs DefinitionErrors
+	require.ErrorAs(t, err, &defErrs)
+
+	var messages []string
+	for _, defErr := range defErrs {
+		messages = append(messages, defErr.Error())
+	}
+	assert.Equal(t, []string{
+		`command "app": default command "serve" does not exist`,
+		`command "app": command name "d" is used by both "deploy" and "delete"`,
+		`command "app": flag name "c" is used by both "config" and "color"`,
+		`command "app": invalid default value for flag user: must not be empty`,
+		`command "app deploy": invalid default value for argument service: must not be empty`,
+		`command "app deploy": args hosts has min[2] > max[1]`,
+		`command "app delete": StopOnNthArg must be non-negative, got -1`,
+		`command "app delete": flag name "force" is used by both "force" and "force"`,
+	}, messages)
+
+	assert.ErrorIs(t, err, errEmpty)
+	assert.Same(t, cmd.Commands[0], defErrs[4].Command)
+
+	var multiErr MultiError
+	require.ErrorAs(t, err, &multiErr)
+	assert.Len(t, multiErr.Errors(), 8)
+}
+
+func TestCommandValidateValid(t *testing.T) {
+	cmd := &Command{
+		Name:           "app",
+		Writer:         io.Discard,
+		DefaultCommand: "serve",
+		Flags: []Flag{
+			&StringFlag{Name: "config", Aliases: []string{"c"}, Local: true},
+		},
+		Commands: []*Command{
+			{
+				Name: "serve",
+				Flags: []Flag{
+					&StringFlag{Name: "config", Aliases: []string{"c"}},
+				},
+				Arguments: AnyArguments,
+			},
+		},
+	}
+	require.NoError(t, cmd.Validate())
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app", "--help"}))
+	require.NoError(t, cmd.Validate())
+}
+
+func TestCommandProvenance(t *testing.T) {
+	t.Setenv("TEST_PROVENANCE_PORT", "8080")
+
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("secret"), 0o600))
+
+	config := NewMapSource("config", map[any]any{"db": map[any]any{"host": "db.local"}})
+
+	cmd := &Command{
+		Name: "app",
+		Flags: []Flag{
+			&BoolFlag{Name: "verbose"},
+			&IntFlag{Name: "port", Sources: EnvVars("TEST_PROVENANCE_PORT")},
+			&StringFlag{Name: "token", Sources: Files(path)},
+			&StringFlag{Name: "db-host", Sources: NewValueSourceChain(NewMapValueSource("db.host", config))},
+			&StringFlag{Name: "region", Value: "us-east"},
+		},
+		Action: func(context.Context, *Command) error { return nil },
+	}
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app", "--verbose"}))
+
+	tests := []struct {
+		name     string
+		origin   ValueOrigin
+		expected string
+	}{
+		{name: "verbose", origin: OriginCommandLine, expected: "command line"},
+		{name: "port", origin: OriginSource, expected: `environment variable "TEST_PROVENANCE_PORT"`},
+		{name: "token", origin: OriginSource, expected: fmt.Sprintf("file %q", path)},
+		{name: "db-host", origin: OriginSource, expected: `key "db.host" from map source "config"`},
+		{name: "region", origin: OriginDefault, expected: "default"},
+		{name: "nope", origin: OriginDefault, expected: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := cmd.Provenance(tt.name)
+			assert.Equal(t, tt.origin, p.Origin)
+			assert.Equal(t, tt.expected, p.String())
+		})
+	}
+}
+
+func TestCommandEnvPrefix(t *testing.T) {
+	t.Setenv("MYAPP_VERBOSE", "true")
+	t.Setenv("MYAPP_DEPLOY_DRY_RUN", "true")
+	t.Setenv("MYAPP_DEPLOY_REGION", "eu-west")
+	t.Setenv("MYAPP_DEPLOY_TOKEN", "from-prefix")
+	t.Setenv("DEPLOY_TOKEN", "from-explicit")
+	t.Setenv("MYAPP_DEPLOY_SECRET", "leaked")
+	t.Setenv("MYAPP_DEPLOY_SERVICE_V2_COLOR", "true")
+
+	out := &bytes.Buffer{}
+	cmd := &Command{
+		Name:      "myapp",
+		EnvPrefix: "MYAPP",
+		Writer:    out,
+		Flags: []Flag{
+			&BoolFlag{Name: "verbose"},
+		},
+		Commands: []*Command{
+			{
+				Name: "deploy",
+				Flags: []Flag{
+					&BoolFlag{Name: "dry-run"},
+					&StringFlag{Name: "region", Sources: Files("/does/not/exist")},
+					&StringFlag{Name: "token", Sources: EnvVars("DEPLOY_TOKEN")},
+					&StringFlag{Name: "secret", NoEnvPrefix: true},
+				},
+				Commands: []*Command{
+					{
+						Name: "service.v2",
+						Flags: []Flag{
+							&BoolWithInverseFlag{Name: "color"},
+						},
+						Action: func(context.Context, *Command) error { return nil },
+					},
+				},
+				Action: func(context.Context, *Command) error { return nil },
+			},
+		},
+	}
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"myapp", "deploy"}))
+
+	deploy := cmd.Command("deploy")
+	assert.True(t, deploy.Bool("verbose"))
+	assert.True(t, deploy.Bool("dry-run"))
+	assert.Equal(t, "eu-west", deploy.String("region"))
+	assert.Equal(t, "from-explicit", deploy.String("token"))
+	assert.Equal(t, "", deploy.String("secret"))
+	assert.Equal(t, `environment variable "MYAPP_DEPLOY_DRY_RUN"`, deploy.Provenance("dry-run").String())
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"myapp", "deploy", "service.v2"}))
+	assert.True(t, deploy.Command("service.v2").Bool("color"))
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"myapp", "deploy", "--help"}))
+	assert.Regexp(t, `--dry-run +\[\$MYAPP_DEPLOY_DRY_RUN\]`, out.String())
+	assert.Regexp(t, `--region string +\[\$MYAPP_DEPLOY_REGION\]`, out.String())
+	assert.Regexp(t, `--token string +\[\$DEPLOY_TOKEN\]`, out.String())
+	assert.Regexp(t, `--verbose +\[\$MYAPP_VERBOSE\]`, out.String())
+	assert.NotContains(t, out.String(), "MYAPP_DEPLOY_SECRET")
+	assert.NotContains(t, out.String(), "MYAPP_DEPLOY_HELP")
+}
+
+func TestCommandEnvPrefixFlagNamedLikeBuiltin(t *testing.T) {
+	t.Setenv("MYAPP_VERSION", "1.2.3")
+	t.Setenv("MYAPP_HELP", "true")
+
+	var ran bool
+	cmd := &Command{
+		Name:      "myapp",
+		EnvPrefix: "MYAPP",
+		Flags: []Flag{
+			&StringFlag{Name: "version"},
+		},
+		Action: func(context.Context, *Command) error {
+			ran = true
+			return nil
+		},
+	}
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"myapp"}))
+	assert.True(t, ran, "the built-in help flag must not read MYAPP_HELP")
+	assert.Equal(t, "1.2.3", cmd.String("version"))
+	assert.Equal(t, `environment variable "MYAPP_VERSION"`, cmd.Provenance("version").String())
+}
+
+func TestCommandWatchSources(t *testing.T) {
+	dir := t.TempDir()
+	levelPath := filepath.Join(dir, "level")
+	configPath := filepath.Join(dir, "config.json")
+	write := func(path, content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write(levelPath, "info")
+	write(configPath, `{"workers": 2, "hosts": ["a", "b"]}`)
+
+	cfg := NewJSONConfig(configPath)
+	var (
+		level   string
+		changes []any
+		errs    []error
+	)
+	onChange := func(_ context.Context, _ *Command, v any) { changes = append(changes, v) }
+
+	cmd := &Command{
+		Flags: []Flag{
+			&StringFlag{
+				Name:        "level",
+				Destination: &level,
+				Sources:     FilesWithOptions(FileOptions{TrimSpace: true}, levelPath),
+				Validator: func(s string) error {
+					if s == "bad" {
+						return fmt.Errorf("unknown level %q", s)
+					}
+					return nil
+				},
+				OnChange: func(ctx context.Context, cmd *Command, v string) { onChange(ctx, cmd, v) },
+			},
+			&IntFlag{
+				Name:     "workers",
+				Sources:  NewValueSourceChain(cfg.Key("workers")),
+				OnChange: func(ctx context.Context, cmd *Command, v int) { onChange(ctx, cmd, v) },
+			},
+			&StringSliceFlag{
+				Name:     "hosts",
+				Sources:  NewValueSourceChain(cfg.Key("hosts")),
+				OnChange: func(ctx context.Context, cmd *Command, v []string) { onChange(ctx, cmd, v) },
+			},
+			&StringFlag{
+				Name:     "name",
+				Sources:  NewValueSourceChain(cfg.Key("name")),
+				OnChange: func(ctx context.Context, cmd *Command, v string) { onChange(ctx, cmd, v) },
+			},
+		},
+	}
+	poll := func() { cmd.pollSources(context.Background(), func(err error) { errs = append(errs, err) }) }
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app", "--name", "cli"}))
+	assert.Equal(t, "info", level)
+
+	poll()
+	assert.Empty(t, changes, "nothing changed")
+
+	write(levelPath, "debug\n")
+	write(configPath, `{"workers": 4, "hosts": ["a", "b"], "name": "config"}`)
+	poll()
+	assert.Equal(t, []any{"debug", 4}, changes)
+	assert.Equal(t, "debug", level)
+	assert.Equal(t, 4, cmd.Int("workers"))
+	assert.Equal(t, "cli", cmd.String("name"), "flags given on the command line keep their value")
+	assert.Equal(t, "file "+fmt.Sprintf("%q", levelPath), cmd.Provenance("level").String())
+	assert.Empty(t, errs)
+
+	changes = nil
+	write(levelPath, "bad")
+	write(configPath, `{"workers": "many", "hosts": ["c"]}`)
+	poll()
+	assert.Equal(t, []any{[]string{"c"}}, changes)
+	assert.Equal(t, "debug", level, "invalid values are not applied")
+	assert.Equal(t, 4, cmd.Int("workers"))
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], fmt.Sprintf(`invalid value from file %q for flag level: unknown level "bad"`, levelPath))
+	assert.ErrorContains(t, errs[1], `could not parse "many" as int value from key "workers" from json file`)
+
+	t.Run("polls until done", func(t *testing.T) {
+		write(levelPath, "warn")
+		changes = nil
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cmd.Flags[0].(*StringFlag).OnChange = func(context.Context, *Command, string) { cancel() }
+
+		done := make(chan struct{})
+		go func() {
+			cmd.WatchSources(ctx, time.Millisecond, nil)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("WatchSources did not return after the context was done")
+		}
+		assert.Equal(t, "warn", level)
+	})
+}
+
+func TestCommandWatchSourcesDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("APP_LEVEL=info\n"), 0o644))
+
+	env := NewDotEnv(path)
+	var changes []string
+	cmd := &Command{
+		Flags: []Flag{
+			&StringFlag{
+				Name:     "level",
+				Sources:  NewValueSourceChain(env.EnvVar("APP_LEVEL")),
+				OnChange: func(_ context.Context, _ *Command, v string) { changes = append(changes, v) },
+			},
+		},
+	}
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+	assert.Equal(t, "info", cmd.String("level"))
+
+	require.NoError(t, os.WriteFile(path, []byte("APP_LEVEL=debug\n"), 0o644))
+	cmd.pollSources(context.Background(), func(err error) { t.Error(err) })
+	assert.Equal(t, []string{"debug"}, changes)
+	assert.Equal(t, "debug", cmd.String("level"))
+}
+
+func TestCommandWatchSourcesConcurrentReads(t *testing.T) {
+	levelPath := filepath.Join(t.TempDir(), "level")
+	require.NoError(t, os.WriteFile(levelPath, []byte("info"), 0o644))
+
+	var level string
+	cmd := &Command{
+		Flags: []Flag{
+			&StringFlag{
+				Name:        "level",
+				Destination: &level,
+				Sources:     Files(levelPath),
+				OnChange:    func(context.Context, *Command, string) {},
+			},
+			&StringSliceFlag{
+				Name:     "hosts",
+				Sources:  Files(levelPath),
+				OnChange: func(context.Context, *Command, []string) {},
+			},
+		},
+		Action: func(ctx context.Context, cmd *Command) error {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			done := make(chan struct{})
+			go func() {
+				cmd.WatchSources(ctx, time.Millisecond, nil)
+				close(done)
+			}()
+			defer func() { <-done }()
+			defer cancel()
+
+			require.NoError(t, os.WriteFile(levelPath, []byte("debug"), 0o644))
+			deadline := time.After(5 * time.Second)
+			for cmd.String("level") != "debug" || !slices.Equal(cmd.StringSlice("hosts"), []string{"debug"}) {
+				_ = cmd.IsSet("level")
+				_ = cmd.Provenance("hosts")
+				select {
+				case <-deadline:
+					return fmt.Errorf("value not updated, got %q", cmd.String("level"))
+				default:
+				}
+			}
+			return nil
+		},
+	}
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+	assert.Equal(t, "debug", level)
+}
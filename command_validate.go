@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+)
+
+// definitionValidator is implemented by flags and arguments which can
+// check their own definition for mistakes
+type definitionValidator interface {
+	validateDefinition() error
+}
+
+// Validate checks the definition of cmd and its sub-commands for mistakes
+// which would otherwise only surface when running them, such as two flags
+// sharing a name or a default command which does not exist. It returns
+// DefinitionErrors holding every mistake found, or nil if there is none.
+func (cmd *Command) Validate() error {
+	var errs DefinitionErrors
+
+	_ = cmd.Walk(func(c *Command) error {
+		// link the sub-commands to report their full name
+		for _, sub := range c.Commands {
+			sub.parent = c
+		}
+		for _, err := range c.validateDefinition() {
+			errs = append(errs, &DefinitionError{Command: c, Err: err})
+		}
+		return nil
+	})
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateDefinition returns the mistakes in the definition of cmd itself,
+// not in the one of its sub-commands
+func (cmd *Command) validateDefinition() []error {
+	var errs []error
+
+	if cmd.StopOnNthArg != nil && *cmd.StopOnNthArg < 0 {
+		errs = append(errs, fmt.Errorf("StopOnNthArg must be non-negative, got %d", *cmd.StopOnNthArg))
+	}
+
+	if cmd.DefaultCommand != "" && cmd.Command(cmd.DefaultCommand) == nil {
+		errs = append(errs, fmt.Errorf("default command %q does not exist", cmd.DefaultCommand))
+	}
+
+	commandNames := map[string]*Command{}
+	for _, sub := range cmd.Commands {
+		for _, name := range sub.Names() {
+			if other, ok := commandNames[name]; ok && other != sub {
+				errs = append(errs, fmt.Errorf("command name %q is used by both %q and %q", name, other.Name, sub.Name))
+				continue
+			}
+			commandNames[name] = sub
+		}
+	}
+
+	flagNames := map[string]Flag{}
+	for _, fl := range cmd.allFlags() {
+		for _, name := range fl.Names() {
+			if other, ok := flagNames[name]; ok {
+				errs = append(errs, fmt.Errorf("flag name %q is used by both %q and %q", name, other.Names()[0], fl.Names()[0]))
+				continue
+			}
+			flagNames[name] = fl
+		}
+		if dv, ok := fl.(definitionValidator); ok {
+			if err := dv.validateDefinition(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, arg := range cmd.Arguments {
+		if dv, ok := arg.(definitionValidator); ok {
+			if err := dv.validateDefinition(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"time"
+)
+
+// watchedFlag is implemented by flags which can be updated when the value
+// of their sources changes
+type watchedFlag interface {
+	pollSources(ctx context.Context, cmd *Command) error
+}
+
+// reloadableSource is implemented by value sources which cache what they
+// read, and read it again on the next lookup once reloaded
+type reloadableSource interface {
+	reload()
+}
+
+// WatchSources polls the sources of the flags of the command and of its
+// ancestors which have an OnChange callback every interval, until ctx is
+// done, for long-running commands such as daemons started by an Action.
+//
+// Flags given on the command line keep their value. When the value read
+// from the sources of another flag changes, it is checked by the Validator
+// of the flag and then replaces the previous value, in its Destination if
+// it has one, before OnChange is called with it. A value which can't be
+// read, parsed or validated is passed to onError, if not nil, and the flag
+// keeps its previous value.
+//
+// WatchSources blocks until ctx is done, so it is typically run in its own
+// goroutine. OnChange callbacks are called from that goroutine. The value
+// and the Destination are replaced together, with a single assignment
+// each, under a lock which is also held by the accessors of the command,
+// such as Command.String or Command.Value, so these see the update
+// atomically from any goroutine. The lock can't cover code which reads a
+// Destination directly: only the goroutine running WatchSources and the
+// OnChange callbacks may do so, other goroutines must use the accessors of
+// the command or the value passed to OnChange.
+func (cmd *Command) WatchSources(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cmd.pollSources(ctx, onError)
+		}
+	}
+}
+
+// pollSources reloads the sources of the flags of cmd and its ancestors,
+// then updates the flags whose value changed
+func (cmd *Command) pollSources(ctx context.Context, onError func(error)) {
+	type watched struct {
+		cmd  *Command
+		flag watchedFlag
+	}
+
+	var flags []watched
+	seen := map[Flag]bool{}
+	for c := cmd; c != nil; c = c.parent {
+		for _, fl := range c.allFlags() {
+			wf, ok := fl.(watchedFlag)
+			if !ok || seen[fl] {
+				continue
+			}
+			seen[fl] = true
+			flags = append(flags, watched{cmd: c, flag: wf})
+
+			if sf, ok := fl.(sourcedFlags); ok {
+				for _, src := range sf.valueSources().Chain {
+					if r, ok := src.(reloadableSource); ok {
+						r.reload()
+					}
+				}
+			}
+		}
+	}
+
+	for _, w := range flags {
+		if err := w.flag.pollSources(ctx, w.cmd); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
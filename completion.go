@@ -4,6 +4,8 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -12,8 +14,221 @@ const (
 
 	// This flag is supposed to only be used by the completion script itself to generate completions on the fly.
 	completionFlag = "--generate-shell-completion"
+
+	// completionDirectivesVersion is the first version of the completion
+	// output requested as "--generate-shell-completion=<version>" that
+	// understands directive lines and escaped colons. The completion
+	// scripts shipped with this package request it, while scripts
+	// predating it pass the bare flag and get the plain output.
+	completionDirectivesVersion = 2
+)
+
+// CompletionDirective tells the completion script how to complete the
+// current word in addition to the printed candidates. It is printed as
+// the last line of the completion output, e.g. ":files=json,yaml nospace".
+type CompletionDirective struct {
+	// Files completes file names
+	Files bool
+	// FileExtensions restricts file completion to names with one of the
+	// given extensions, e.g. "json", and implies Files
+	FileExtensions []string
+	// Dirs completes directory names only
+	Dirs bool
+	// NoSpace does not add a space after the completed word
+	NoSpace bool
+	// KeepOrder keeps the candidates in the order they were printed
+	// instead of sorting them
+	KeepOrder bool
+	// NoFileFallback does not complete file names when there are no
+	// candidates
+	NoFileFallback bool
+}
+
+// IsZero returns true if the directive does not ask for anything
+func (d CompletionDirective) IsZero() bool {
+	return !d.Files && len(d.FileExtensions) == 0 && !d.Dirs && !d.NoSpace && !d.KeepOrder && !d.NoFileFallback
+}
+
+// String returns the directive line printed for the completion script
+func (d CompletionDirective) String() string {
+	var words []string
+	if len(d.FileExtensions) > 0 {
+		words = append(words, "files="+strings.Join(d.FileExtensions, ","))
+	} else if d.Files {
+		words = append(words, "files")
+	}
+	if d.Dirs {
+		words = append(words, "dirs")
+	}
+	if d.NoSpace {
+		words = append(words, "nospace")
+	}
+	if d.KeepOrder {
+		words = append(words, "keeporder")
+	}
+	if d.NoFileFallback {
+		words = append(words, "nofiles")
+	}
+	return ":" + strings.Join(words, " ")
+}
+
+// PrintCompletionDirective prints the directive for the completion script.
+// It is meant to be called last by a ShellCompleteFunc, and prints nothing
+// if the directive is empty or the completion script does not understand
+// directives.
+func PrintCompletionDirective(cmd *Command, directive CompletionDirective) {
+	PrintCandidates(cmd, Candidate{Kind: CandidateDirective, Directive: directive})
+}
+
+// CandidateKind is the kind of a completion candidate
+type CandidateKind int
+
+const (
+	// CandidateValue is a value of a flag or positional argument
+	CandidateValue CandidateKind = iota
+	// CandidateCommand is the name of a subcommand
+	CandidateCommand
+	// CandidateFlag is the name of a flag
+	CandidateFlag
+	// CandidateDirective tells the completion script how to complete the
+	// current word in addition to the other candidates
+	CandidateDirective
 )
 
+func (k CandidateKind) String() string {
+	switch k {
+	case CandidateValue:
+		return "value"
+	case CandidateCommand:
+		return "command"
+	case CandidateFlag:
+		return "flag"
+	case CandidateDirective:
+		return "directive"
+	}
+	return fmt.Sprintf("CandidateKind(%d)", int(k))
+}
+
+// Candidate is a possible completion of the current word
+type Candidate struct {
+	// Value is the completed word
+	Value string
+	// Description is shown next to the value by shells supporting it
+	Description string
+	// Kind is the kind of the candidate
+	Kind CandidateKind
+	// Directive is the directive of a candidate of kind CandidateDirective
+	Directive CompletionDirective
+}
+
+// Complete returns the completion candidates for the last element of args,
+// which hold the command line as passed to Run, e.g. []string{"app",
+// "deploy", "--region", ""} to complete the value of --region. The command
+// chain is resolved the same way as by Run and the ShellComplete function
+// of the resolved command is called. Arguments are not read from stdin,
+// even when ReadArgsFromStdin is set.
+//
+// Complete must be called on the root command and returns nil unless
+// EnableShellCompletion is set. Candidates written directly to the Writer
+// by a custom ShellComplete function, rather than with PrintCandidates,
+// are not returned.
+//
+// Complete works on a copy of cmd made with Clone, leaving cmd as it was,
+// but the Before functions of the command chain are still run, as they
+// would be in shell completion mode, and the Destination of the flags and
+// arguments given are still set. Complete is therefore not safe for
+// concurrent use unless these don't share any state.
+func (cmd *Command) Complete(ctx context.Context, args []string) []Candidate {
+	if !cmd.EnableShellCompletion || len(args) == 0 {
+		return nil
+	}
+
+	// the completion scripts only pass the word being completed if it is
+	// a flag, and leave matching the other candidates to the shell
+	args = slices.Clone(args)
+	cur := args[len(args)-1]
+	if !strings.HasPrefix(cur, "-") {
+		args = args[:len(args)-1]
+	}
+	args = append(args, fmt.Sprintf("%s=%d", completionFlag, completionDirectivesVersion))
+
+	var candidates []Candidate
+	c := cmd.Clone()
+	c.ReadArgsFromStdin = false
+	c.completionCandidates = &candidates
+
+	_ = c.Run(ctx, args)
+
+	if strings.HasPrefix(cur, "-") {
+		return candidates
+	}
+	return slices.DeleteFunc(candidates, func(c Candidate) bool {
+		return c.Kind != CandidateDirective && !strings.HasPrefix(c.Value, cur)
+	})
+}
+
+// PrintCandidates prints the candidates for the completion script, or
+// returns them from Complete. It is meant to be used by a ShellCompleteFunc.
+// Candidates of kind CandidateDirective should come last, and are only
+// printed for completion scripts understanding directives.
+func PrintCandidates(cmd *Command, candidates ...Candidate) {
+	root := cmd.Root()
+	if root.completionCandidates != nil {
+		*root.completionCandidates = append(*root.completionCandidates, candidates...)
+		return
+	}
+
+	// colons are escaped for completion scripts that understand
+	// directives, since they split descriptions off at the first
+	// unescaped colon
+	directives := root.completionVersion >= completionDirectivesVersion
+	for _, c := range candidates {
+		if c.Kind == CandidateDirective {
+			if directives && !c.Directive.IsZero() {
+				_, _ = fmt.Fprintln(root.Writer, c.Directive)
+			}
+			continue
+		}
+
+		value := c.Value
+		if directives {
+			value = strings.ReplaceAll(value, ":", `\:`)
+		}
+		if c.Description != "" {
+			_, _ = fmt.Fprintf(root.Writer, "%s:%s\n", value, c.Description)
+		} else {
+			_, _ = fmt.Fprintln(root.Writer, value)
+		}
+	}
+}
+
+// trimCompletionFlag returns args without the trailing completion flag
+func trimCompletionFlag(args []string) []string {
+	if len(args) > 0 {
+		if _, ok := parseCompletionFlag(args[len(args)-1]); ok {
+			return args[:len(args)-1]
+		}
+	}
+	return args
+}
+
+// parseCompletionFlag returns whether arg is the completion flag and the
+// version of the completion output it asks for
+func parseCompletionFlag(arg string) (int, bool) {
+	if arg == completionFlag {
+		return 1, true
+	}
+	v, ok := strings.CutPrefix(arg, completionFlag+"=")
+	if !ok {
+		return 0, false
+	}
+	version, err := strconv.Atoi(v)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
 type renderCompletion func(cmd *Command, appName string) (string, error)
 
 var (
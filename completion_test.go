@@ -181,10 +181,44 @@ func TestCompletionBashAppendsSpace(t *testing.T) {
 
 	output, err := bashRender(cmd, "myapp")
 	r.NoError(err)
-	r.NotContains(output, "-o nospace", "bash completion should append spaces after completed words")
+	// Only the nospace directive may turn off the space for a single completion.
+	r.NotRegexp(`(?m)^complete .*-o nospace`, output, "bash completion should append spaces after completed words")
 	r.Contains(output, "complete -o bashdefault -o default -F __myapp_bash_autocomplete myapp")
 }
 
+func TestCompletionScriptsRequestDirectives(t *testing.T) {
+	// Every completion script must ask for the completion output with
+	// directives, since they no longer guess when to complete file names.
+
+	cmd := &Command{
+		EnableShellCompletion: true,
+	}
+
+	for _, shell := range completionShells {
+		t.Run(shell, func(t *testing.T) {
+			output, err := shellCompletions[shell](cmd, "myapp")
+			require.NoError(t, err)
+			assert.Contains(t, output, fmt.Sprintf("%s=%d", completionFlag, completionDirectivesVersion))
+		})
+	}
+}
+
+func TestCompletionDirectiveString(t *testing.T) {
+	tests := []struct {
+		directive CompletionDirective
+		expected  string
+	}{
+		{CompletionDirective{}, ":"},
+		{CompletionDirective{Files: true}, ":files"},
+		{CompletionDirective{Files: true, FileExtensions: []string{"json", "yaml"}}, ":files=json,yaml"},
+		{CompletionDirective{Dirs: true, NoSpace: true, KeepOrder: true, NoFileFallback: true}, ":dirs nospace keeporder nofiles"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.directive.String())
+	}
+}
+
 func TestCompletionBashGreedyColonParsing(t *testing.T) {
 	// Regression test for https://github.com/urfave/cli/issues/2335
 	// The bash completion template uses fmt.Sprintf, so
@@ -257,8 +291,8 @@ func TestCompletionFishOmitsPositionalTokenFromDynamicCompletion(t *testing.T) {
 	r.NoError(err)
 
 	r.Contains(output, `if string match -q -- "-*" $lastArg`)
-	r.Contains(output, "set results ($args[1] $args[2..-1] $lastArg --generate-shell-completion 2> /dev/null)")
-	r.Contains(output, "set results ($args[1] $args[2..-1] --generate-shell-completion 2> /dev/null)")
+	r.Contains(output, "set results ($args[1] $args[2..-1] $lastArg --generate-shell-completion=2 2> /dev/null)")
+	r.Contains(output, "set results ($args[1] $args[2..-1] --generate-shell-completion=2 2> /dev/null)")
 }
 
 func TestCompletionBashOmitsPositionalTokenFromDynamicCompletion(t *testing.T) {
@@ -276,8 +310,8 @@ func TestCompletionBashOmitsPositionalTokenFromDynamicCompletion(t *testing.T) {
 	r.NoError(err)
 
 	r.Contains(output, `if [[ "${current_word}" == "-"* ]]; then`)
-	r.Contains(output, `printf '%s %s --generate-shell-completion' "${words_before_cursor[*]}" "${current_word}"`)
-	r.Contains(output, `printf '%s --generate-shell-completion' "${words_before_cursor[*]}"`)
+	r.Contains(output, `printf '%s %s --generate-shell-completion=2' "${words_before_cursor[*]}" "${current_word}"`)
+	r.Contains(output, `printf '%s --generate-shell-completion=2' "${words_before_cursor[*]}"`)
 }
 
 func TestCompletionSubcommand(t *testing.T) {
@@ -569,3 +603,263 @@ func TestCompletionShellWriteError(t *testing.T) {
 	err := cmd.Run(buildTestContext(t), []string{"foo", completionCommandName, shellName})
 	assert.ErrorContains(t, err, "writer error")
 }
+
+func TestCommandComplete(t *testing.T) {
+	out := &bytes.Buffer{}
+	cmd := &Command{
+		Name:                  "app",
+		EnableShellCompletion: true,
+		Writer:                out,
+		Flags: []Flag{
+			&BoolFlag{Name: "verbose", Usage: "more output"},
+		},
+		Commands: []*Command{
+			{
+				Name:  "deploy",
+				Usage: "deploy a service",
+				Flags: []Flag{
+					&StringFlag{
+						Name: "region",
+						ShellComplete: func(context.Context, *Command) []string {
+							return []string{"us-east", "eu-west", "http://eu:80"}
+						},
+					},
+					&StringFlag{Name: "config", TakesFile: true},
+				},
+				Arguments: []Argument{
+					&StringArg{
+						Name: "service",
+						ShellComplete: func(context.Context, *Command) []string {
+							return []string{"api", "worker"}
+						},
+					},
+				},
+			},
+			{
+				Name:  "destroy",
+				Usage: "destroy a service",
+				ShellComplete: func(ctx context.Context, cmd *Command) {
+					PrintCandidates(cmd, Candidate{Value: "everything", Description: "no way back"})
+					PrintCompletionDirective(cmd, CompletionDirective{NoFileFallback: true})
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []Candidate
+	}{
+		{
+			name: "commands",
+			args: []string{"app", "de"},
+			expected: []Candidate{
+				{Value: "deploy", Description: "deploy a service", Kind: CandidateCommand},
+				{Value: "destroy", Description: "destroy a service", Kind: CandidateCommand},
+			},
+		},
+		{
+			name: "flags",
+			args: []string{"app", "--verb"},
+			expected: []Candidate{
+				{Value: "--verbose", Description: "more output", Kind: CandidateFlag},
+				{Kind: CandidateDirective, Directive: CompletionDirective{NoFileFallback: true}},
+			},
+		},
+		{
+			name: "flag values",
+			args: []string{"app", "deploy", "--region", ""},
+			expected: []Candidate{
+				{Value: "us-east", Kind: CandidateValue},
+				{Value: "eu-west", Kind: CandidateValue},
+				{Value: "http://eu:80", Kind: CandidateValue},
+			},
+		},
+		{
+			name: "flag values with prefix",
+			args: []string{"app", "deploy", "--region", "eu"},
+			expected: []Candidate{
+				{Value: "eu-west", Kind: CandidateValue},
+			},
+		},
+		{
+			name: "file flag",
+			args: []string{"app", "deploy", "--config", ""},
+			expected: []Candidate{
+				{Kind: CandidateDirective, Directive: CompletionDirective{Files: true}},
+			},
+		},
+		{
+			name: "argument",
+			args: []string{"app", "deploy", "--region", "us-east", "w"},
+			expected: []Candidate{
+				{Value: "worker", Kind: CandidateValue},
+			},
+		},
+		{
+			name: "custom shell complete",
+			args: []string{"app", "destroy", ""},
+			expected: []Candidate{
+				{Value: "everything", Description: "no way back"},
+				{Kind: CandidateDirective, Directive: CompletionDirective{NoFileFallback: true}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, cmd.Complete(buildTestContext(t), tt.args))
+			assert.Empty(t, out.String())
+		})
+	}
+}
+
+func TestCommandCompleteDisabled(t *testing.T) {
+	cmd := &Command{
+		Name:     "app",
+		Commands: []*Command{{Name: "deploy"}},
+	}
+	assert.Nil(t, cmd.Complete(buildTestContext(t), []string{"app", ""}))
+}
+
+func TestCommandCompleteLeavesCommand(t *testing.T) {
+	cmd := &Command{
+		Name:                  "app",
+		EnableShellCompletion: true,
+		ReadArgsFromStdin:     true,
+		Reader:                strings.NewReader("--verbose"),
+		Flags:                 []Flag{&BoolFlag{Name: "verbose"}},
+		Commands:              []*Command{{Name: "deploy"}, {Name: "destroy"}},
+	}
+
+	expected := []Candidate{
+		{Value: "deploy", Kind: CandidateCommand},
+		{Value: "destroy", Kind: CandidateCommand},
+	}
+	assert.Equal(t, expected, cmd.Complete(buildTestContext(t), []string{"app", "--verbose", "de"}))
+	assert.False(t, cmd.Bool("verbose"))
+	assert.Nil(t, cmd.completionCandidates)
+
+	data, err := io.ReadAll(cmd.Reader)
+	require.NoError(t, err)
+	assert.Equal(t, "--verbose", string(data), "stdin must not be read")
+}
+
+func TestCommandCompleteParallel(t *testing.T) {
+	cmd := &Command{
+		Name:                  "app",
+		EnableShellCompletion: true,
+		Commands: []*Command{
+			{
+				Name:  "deploy",
+				Flags: []Flag{&StringFlag{Name: "region"}},
+				ShellComplete: func(_ context.Context, cmd *Command) {
+					PrintCandidates(cmd, Candidate{Value: cmd.String("region")})
+				},
+			},
+		},
+	}
+
+	for _, region := range []string{"us-east", "eu-west", "ap-south"} {
+		t.Run(region, func(t *testing.T) {
+			t.Parallel()
+			for i := 0; i < 10; i++ {
+				candidates := cmd.Complete(context.Background(), []string{"app", "deploy", "--region", region, ""})
+				assert.Equal(t, []Candidate{{Value: region}}, candidates)
+			}
+		})
+	}
+}
+
+func TestCommandCompleteFlagsInUse(t *testing.T) {
+	newCmd := func() *Command {
+		return &Command{
+			Name:                  "app",
+			EnableShellCompletion: true,
+			Flags: []Flag{
+				&BoolFlag{Name: "verbose"},
+				&StringFlag{Name: "token", OnlyOnce: true},
+				&StringFlag{Name: "trace", Local: true},
+			},
+			Commands: []*Command{
+				{
+					Name: "deploy",
+					Flags: []Flag{
+						&StringSliceFlag{Name: "tag"},
+					},
+					MutuallyExclusiveFlags: []MutuallyExclusiveFlags{
+						{
+							Flags: [][]Flag{
+								{&BoolFlag{Name: "tls"}, &StringFlag{Name: "tls-cert"}},
+								{&BoolFlag{Name: "tls-off"}},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	values := func(candidates []Candidate) []string {
+		var values []string
+		for _, c := range candidates {
+			if c.Kind == CandidateFlag {
+				values = append(values, c.Value)
+			}
+		}
+		return values
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "inherited persistent flags",
+			args:     []string{"app", "deploy", "--t"},
+			expected: []string{"--tag", "--tls", "--tls-cert", "--tls-off", "--token"},
+		},
+		{
+			name:     "only once flag already given",
+			args:     []string{"app", "--token", "abc", "deploy", "--t"},
+			expected: []string{"--tag", "--tls", "--tls-cert", "--tls-off"},
+		},
+		{
+			name:     "repeatable flag already given",
+			args:     []string{"app", "deploy", "--tag", "a", "--t"},
+			expected: []string{"--tag", "--tls", "--tls-cert", "--tls-off", "--token"},
+		},
+		{
+			name:     "mutually exclusive flag already given",
+			args:     []string{"app", "deploy", "--tls", "--t"},
+			expected: []string{"--tag", "--tls", "--tls-cert", "--token"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := newCmd().Complete(buildTestContext(t), tt.args)
+			assert.ElementsMatch(t, tt.expected, values(candidates))
+		})
+	}
+}
+
+func TestPrintCandidates(t *testing.T) {
+	candidates := []Candidate{
+		{Value: "deploy", Description: "deploy a service", Kind: CandidateCommand},
+		{Value: "http://eu:80"},
+		{Kind: CandidateDirective, Directive: CompletionDirective{NoSpace: true}},
+	}
+
+	out := &bytes.Buffer{}
+	cmd := &Command{Writer: out}
+	PrintCandidates(cmd, candidates...)
+	assert.Equal(t, "deploy:deploy a service\nhttp://eu:80\n", out.String())
+
+	out.Reset()
+	cmd.completionVersion = completionDirectivesVersion
+	PrintCandidates(cmd, candidates...)
+	assert.Equal(t, "deploy:deploy a service\nhttp\\://eu\\:80\n:nospace\n", out.String())
+}
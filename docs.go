@@ -95,8 +95,11 @@ func stringifyFlag(f Flag) string {
 	needsPlaceholder := df.TakesValue()
 	// if needsPlaceholder is true, placeholder is empty
 	if needsPlaceholder && placeholder == "" {
+		// list the allowed values of the flag if it has any, otherwise
 		// try to get type from flag
-		if tname := df.TypeName(); tname != "" {
+		if cf, ok := f.(ChoicesFlag); ok && len(cf.GetChoices()) > 0 {
+			placeholder = strings.Join(cf.GetChoices(), "|")
+		} else if tname := df.TypeName(); tname != "" {
 			placeholder = tname
 		} else {
 			placeholder = defaultPlaceholder
@@ -126,3 +129,33 @@ func stringifyFlag(f Flag) string {
 
 	return withEnvHint(df.GetEnvVars(), fmt.Sprintf("%s\t%s", pn, usageWithDefault))
 }
+
+func stringifyArgument(a Argument) string {
+	da, ok := a.(DocGenerationArgument)
+	if !ok {
+		return ""
+	}
+
+	placeholder := da.TypeName()
+	if cf, ok := a.(ChoicesFlag); ok && len(cf.GetChoices()) > 0 {
+		placeholder = strings.Join(cf.GetChoices(), "|")
+	}
+	if da.IsMultiValue() {
+		placeholder = "..." + placeholder
+	}
+
+	defaultValueString := ""
+
+	// don't print default text for required arguments
+	if rf, ok := a.(RequiredFlag); (!ok || !rf.IsRequired()) && da.IsDefaultVisible() {
+		if s := da.GetDefaultText(); s != "" {
+			defaultValueString = fmt.Sprintf(formatDefault("%s"), s)
+		} else if v := da.GetValue(); v != "" {
+			defaultValueString = fmt.Sprintf(formatDefault("%s"), v)
+		}
+	}
+
+	description := strings.TrimSpace(da.GetDescription() + defaultValueString)
+
+	return withEnvHint(da.GetEnvVars(), strings.TrimSpace(da.GetName()+" "+placeholder)+"\t"+description)
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -45,6 +46,130 @@ func (m *multiError) Errors() []error {
 	return errs
 }
 
+// DefinitionError describes a mistake in the definition of a command,
+// as found by Command.Validate
+type DefinitionError struct {
+	// Command is the command the mistake has been found in
+	Command *Command
+	// Err describes the mistake
+	Err error
+}
+
+func (e *DefinitionError) Error() string {
+	return fmt.Sprintf("command %q: %s", e.Command.FullName(), e.Err)
+}
+
+func (e *DefinitionError) Unwrap() error {
+	return e.Err
+}
+
+// DefinitionErrors is the MultiError returned by Command.Validate, holding
+// every mistake found in the definition of a command tree
+type DefinitionErrors []*DefinitionError
+
+// Error implements the error interface.
+func (e DefinitionErrors) Error() string {
+	errs := make([]string, len(e))
+	for i, err := range e {
+		errs[i] = err.Error()
+	}
+
+	return strings.Join(errs, "\n")
+}
+
+// Errors returns the errors as a slice of error
+func (e DefinitionErrors) Errors() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+func (e DefinitionErrors) Unwrap() []error {
+	return e.Errors()
+}
+
+// ValueSourceError is returned when the value of a flag or argument
+// can't be read from one of its sources, for instance from a file which
+// exists but can't be read
+type ValueSourceError struct {
+	// Flag is the name of the flag whose value was looked up, if known
+	Flag string
+	// Argument is the name of the argument whose value was looked up, if
+	// known
+	Argument string
+	// Source is the source which failed to read the value
+	Source ValueSource
+	// Err is the reason of the failure
+	Err error
+}
+
+func (e *ValueSourceError) Error() string {
+	switch {
+	case e.Flag != "":
+		return fmt.Sprintf("could not read value from %s for flag %s: %s", e.Source, e.Flag, e.Err)
+	case e.Argument != "":
+		return fmt.Sprintf("could not read value from %s for argument %s: %s", e.Source, e.Argument, e.Err)
+	default:
+		return fmt.Sprintf("could not read value from %s: %s", e.Source, e.Err)
+	}
+}
+
+func (e *ValueSourceError) Unwrap() error {
+	return e.Err
+}
+
+// sourceError names the flag in the *ValueSourceError err, if it is one
+func sourceError(flag string, err error) error {
+	var vsErr *ValueSourceError
+	if errors.As(err, &vsErr) {
+		vsErr.Flag = flag
+	}
+	return err
+}
+
+// argSourceError names the argument in the *ValueSourceError err, if it
+// is one
+func argSourceError(arg string, err error) error {
+	var vsErr *ValueSourceError
+	if errors.As(err, &vsErr) {
+		vsErr.Argument = arg
+	}
+	return err
+}
+
+// ArgumentError is returned when the value of a positional argument can't
+// be parsed or is rejected by its Validator
+type ArgumentError struct {
+	// Name is the name of the argument
+	Name string
+	// Values are the values given for the argument
+	Values []string
+	// Source is the source the values were read from, or nil when they
+	// were given on the command line
+	Source ValueSource
+	// Err is the reason the values were rejected
+	Err error
+}
+
+func (e *ArgumentError) Error() string {
+	var value string
+	if len(e.Values) == 1 {
+		value = fmt.Sprintf("value %q", e.Values[0])
+	} else {
+		value = fmt.Sprintf("values %q", e.Values)
+	}
+	if e.Source != nil {
+		return fmt.Sprintf("invalid %s for argument %s from %s: %v", value, e.Name, e.Source, e.Err)
+	}
+	return fmt.Sprintf("invalid %s for argument %s: %v", value, e.Name, e.Err)
+}
+
+func (e *ArgumentError) Unwrap() error {
+	return e.Err
+}
+
 type requiredFlagsErr interface {
 	error
 }
@@ -77,6 +202,23 @@ func (e *errRequiredArguments) Error() string {
 	return fmt.Sprintf("Required arguments %q not set", joinedMissingArguments)
 }
 
+type errInvalidChoice struct {
+	value   string
+	choices []string
+}
+
+func (e *errInvalidChoice) Error() string {
+	quoted := make([]string, len(e.choices))
+	for i, choice := range e.choices {
+		quoted[i] = fmt.Sprintf("%q", choice)
+	}
+	msg := fmt.Sprintf("value %q is not one of %s", e.value, strings.Join(quoted, ", "))
+	if suggestion := suggestChoice(e.choices, e.value); suggestion != "" {
+		msg += ". " + fmt.Sprintf(SuggestDidYouMeanTemplate, suggestion)
+	}
+	return msg
+}
+
 type mutuallyExclusiveGroup struct {
 	flag1Name string
 	flag2Name string
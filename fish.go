@@ -31,8 +31,9 @@ func (cmd *Command) writeFishCompletionTemplate(w io.Writer) error {
 		return err
 	}
 
-	// Add global flags
+	// Add global flags and arguments
 	completions := prepareFishFlags(cmd.Name, cmd)
+	completions = append(completions, prepareFishArgs(cmd.Name, cmd)...)
 
 	if cmd.ShellComplete != nil {
 		var completion strings.Builder
@@ -109,6 +110,11 @@ func prepareFishCommands(binary string, parent *Command) []string {
 			prepareFishFlags(binary, command)...,
 		)
 
+		completions = append(
+			completions,
+			prepareFishArgs(binary, command)...,
+		)
+
 		// recursively iterate subcommands
 		completions = append(
 			completions,
@@ -163,17 +169,63 @@ func prepareFishFlags(binary string, owner *Command) []string {
 }
 
 func fishAddFileFlag(flag Flag, completion *strings.Builder) {
-	switch f := flag.(type) {
-	case *StringFlag:
-		if f.TakesFile {
-			return
+	var directive CompletionDirective
+	if dc, ok := flag.(DirectiveCompleter); ok {
+		directive = dc.GetCompletionDirective()
+	}
+
+	switch {
+	case directive.Dirs, len(directive.FileExtensions) > 0:
+		fishAddPathCompletion(directive, completion)
+	case directive.Files:
+	default:
+		completion.WriteString(" -f")
+	}
+}
+
+// prepareFishArgs completes the positional arguments of the command that
+// take files or directories
+func prepareFishArgs(binary string, owner *Command) []string {
+	completions := []string{}
+	for _, arg := range owner.Arguments {
+		dc, ok := arg.(DirectiveCompleter)
+		if !ok {
+			continue
 		}
-	case *StringSliceFlag:
-		if f.TakesFile {
-			return
+		directive := dc.GetCompletionDirective()
+		if !directive.Files && len(directive.FileExtensions) == 0 && !directive.Dirs {
+			continue
 		}
+
+		completion := &strings.Builder{}
+		fmt.Fprintf(completion,
+			"complete -c %s -n '%s'",
+			binary,
+			fishFlagHelper(binary, owner),
+		)
+		if directive.Dirs || len(directive.FileExtensions) > 0 {
+			fishAddPathCompletion(directive, completion)
+		} else {
+			completion.WriteString(" -F")
+		}
+		completions = append(completions, completion.String())
+	}
+	return completions
+}
+
+// fishAddPathCompletion completes directories or files with the extensions
+// asked for by the directive
+func fishAddPathCompletion(directive CompletionDirective, completion *strings.Builder) {
+	if directive.Dirs {
+		completion.WriteString(" -f -a '(__fish_complete_directories)'")
+		return
+	}
+
+	var suffixes []string
+	for _, ext := range directive.FileExtensions {
+		suffixes = append(suffixes, "__fish_complete_suffix ."+ext)
 	}
-	completion.WriteString(" -f")
+	fmt.Fprintf(completion, " -f -a '(%s)'", escapeSingleQuotes(strings.Join(suffixes, "; ")))
 }
 
 func fishSubcommandHelper(binary string, command *Command, siblings []*Command) string {
@@ -92,3 +92,26 @@ func TestFishCompletionShellComplete(t *testing.T) {
 	assert.Contains(t, res, fmt.Sprintf("complete -c greet -n '__fish_seen_subcommand_from config c' -xa '(greet config %s 2>/dev/null)'", completionFlag))
 	assert.Contains(t, res, fmt.Sprintf("complete -c greet -n '__fish_seen_subcommand_from config c; and __fish_seen_subcommand_from sub-config s ss' -xa '(greet config sub-config %s 2>/dev/null)'", completionFlag))
 }
+
+func TestFishCompletionDirectives(t *testing.T) {
+	cmd := &Command{
+		Name: "greet",
+		Flags: []Flag{
+			&StringFlag{Name: "config", TakesFile: true},
+			&StringFlag{Name: "schema", CompletionDirective: CompletionDirective{FileExtensions: []string{"json", "yaml"}}},
+			&StringFlag{Name: "workdir", CompletionDirective: CompletionDirective{Dirs: true}},
+		},
+		Arguments: []Argument{
+			&StringArg{Name: "input", TakesFile: true},
+		},
+	}
+	cmd.setupCommandGraph()
+
+	res, err := cmd.ToFishCompletion()
+	require.NoError(t, err)
+
+	assert.Contains(t, res, "complete -c greet -n '__fish_greet_no_subcommand' -l config -r\n")
+	assert.Contains(t, res, "complete -c greet -n '__fish_greet_no_subcommand' -f -a '(__fish_complete_suffix .json; __fish_complete_suffix .yaml)' -l schema -r\n")
+	assert.Contains(t, res, "complete -c greet -n '__fish_greet_no_subcommand' -f -a '(__fish_complete_directories)' -l workdir -r\n")
+	assert.Contains(t, res, "complete -c greet -n '__fish_greet_no_subcommand' -F\n")
+}
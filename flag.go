@@ -10,6 +10,10 @@ import (
 
 const defaultPlaceholder = "value"
 
+// computedDefaultText is shown in help as the default of flags whose
+// default value is computed by a DefaultFunc, which help doesn't call
+const computedDefaultText = "computed"
+
 const (
 	defaultSliceFlagSeparator       = ","
 	defaultMapFlagKeyValueSeparator = "="
@@ -168,6 +172,28 @@ type SchemaItemsTyper interface {
 	SchemaItemsType() string
 }
 
+// ChoicesFlag is an interface for flags whose value is restricted
+// to a fixed set of choices
+type ChoicesFlag interface {
+	// GetChoices returns the allowed values for the flag, or nil if
+	// any value is accepted
+	GetChoices() []string
+}
+
+// ValueCompleter is an interface for flags and arguments that offer
+// candidate values during shell completion
+type ValueCompleter interface {
+	// CompleteValue returns the candidate values
+	CompleteValue(context.Context, *Command) []string
+}
+
+// DirectiveCompleter is an interface for flags and arguments that tell the
+// completion script how to complete their values, e.g. as file names
+type DirectiveCompleter interface {
+	// GetCompletionDirective returns the completion directive
+	GetCompletionDirective() CompletionDirective
+}
+
 // Countable is an interface to enable detection of flag values which support
 // repetitive flags
 type Countable interface {
@@ -196,6 +222,12 @@ type LocalFlag interface {
 	IsLocal() bool
 }
 
+// OnlyOnceFlag is an interface to enable detection of flags which can only
+// be given once on the command line
+type OnlyOnceFlag interface {
+	IsOnlyOnce() bool
+}
+
 func visibleFlags(fl []Flag) []Flag {
 	var visible []Flag
 	for _, f := range fl {
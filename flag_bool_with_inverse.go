@@ -29,12 +29,14 @@ type BoolWithInverseFlag struct {
 	ValidateDefaults bool                                        `json:"validateDefaults"` // whether to validate defaults or not
 	Config           BoolConfig                                  `json:"config"`           // Additional/Custom configuration associated with this flag type
 	InversePrefix    string                                      `json:"invPrefix"`        // The prefix used to indicate a negative value. Default: `env` becomes `no-env`
+	NoEnvPrefix      bool                                        `json:"noEnvPrefix"`      // whether to not derive an environment variable from the EnvPrefix of the root command
 
 	// unexported fields for internal use
-	count      int   // number of times the flag has been set
-	hasBeenSet bool  // whether the flag has been set from env or file
-	applied    bool  // whether the flag has been applied to a flag set already
-	value      Value // value representing this flag's value
+	count      int         // number of times the flag has been set
+	hasBeenSet bool        // whether the flag has been set from env or file
+	applied    bool        // whether the flag has been applied to a flag set already
+	value      Value       // value representing this flag's value
+	source     ValueSource // source the value has been read from, if any
 	pset       bool
 	nset       bool
 }
@@ -59,6 +61,56 @@ func (bif *BoolWithInverseFlag) IsLocal() bool {
 	return bif.Local
 }
 
+func (bif *BoolWithInverseFlag) IsOnlyOnce() bool {
+	return bif.OnlyOnce
+}
+
+func (bif *BoolWithInverseFlag) reset() {
+	bif.count = 0
+	bif.hasBeenSet = false
+	bif.applied = false
+	bif.source = nil
+	bif.pset = false
+	bif.nset = false
+	if bif.value != nil {
+		value := bif.Value
+		bif.value = &boolValue{destination: &value, count: &bif.count}
+	}
+}
+
+func (bif *BoolWithInverseFlag) deriveEnvVar(key string) {
+	if bif.NoEnvPrefix || len(bif.Sources.EnvKeys()) > 0 {
+		return
+	}
+	bif.Sources.Append(EnvVars(key))
+}
+
+func (bif *BoolWithInverseFlag) clone() Flag {
+	c := *bif
+	c.Aliases = slices.Clone(bif.Aliases)
+	c.Sources.Chain = slices.Clone(bif.Sources.Chain)
+	c.reset()
+	return &c
+}
+
+func (bif *BoolWithInverseFlag) validateDefinition() error {
+	if bif.Validator == nil || bif.Required || (!bif.Value && !bif.ValidateDefaults) {
+		return nil
+	}
+	if err := bif.Validator(bif.Value); err != nil {
+		return fmt.Errorf("invalid default value for flag %s: %w", bif.Name, err)
+	}
+	return nil
+}
+
+func (bif *BoolWithInverseFlag) valueSources() ValueSourceChain {
+	return bif.Sources
+}
+
+func (bif *BoolWithInverseFlag) valueSource() ValueSource {
+	return bif.source
+}
+
 func (bif *BoolWithInverseFlag) inversePrefix() string {
 	if bif.InversePrefix == "" {
 		bif.InversePrefix = DefaultInverseBoolPrefix
@@ -96,7 +148,11 @@ func (bif *BoolWithInverseFlag) PostParse() error {
 	tracef("postparse (flag=%[1]q)", bif.Name)
 
 	if !bif.hasBeenSet {
-		if val, source, found := bif.Sources.LookupWithSource(); found {
+		val, source, found, err := bif.Sources.LookupWithSourceE()
+		if err != nil {
+			return sourceError(bif.Name, err)
+		}
+		if found {
 			if val == "" {
 				val = "false"
 			}
@@ -108,6 +164,7 @@ func (bif *BoolWithInverseFlag) PostParse() error {
 			}
 
 			bif.hasBeenSet = true
+			bif.source = source
 		}
 	}
 
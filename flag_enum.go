@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type EnumFlag = FlagBase[string, EnumConfig, enumValue]
+
+// EnumConfig defines the configuration for enum flags
+type EnumConfig struct {
+	// Choices is the set of values the flag accepts
+	Choices []string
+	// Whether to trim whitespace of parsed value
+	TrimSpace bool
+}
+
+// configChoices returns the allowed values from the configuration of a
+// flag or argument, if any
+func configChoices(c any) []string {
+	if c, ok := c.(EnumConfig); ok {
+		return c.Choices
+	}
+	return nil
+}
+
+// -- enum Value
+type enumValue struct {
+	destination *string
+	choices     []string
+	trimSpace   bool
+}
+
+// Below functions are to satisfy the ValueCreator interface
+
+func (e enumValue) Create(val string, p *string, c EnumConfig) Value {
+	*p = val
+	return &enumValue{
+		destination: p,
+		choices:     c.Choices,
+		trimSpace:   c.TrimSpace,
+	}
+}
+
+func (e enumValue) ToString(val string) string {
+	e.destination = &val
+	return e.String()
+}
+
+// Below functions are to satisfy the flag.Value interface
+
+func (e *enumValue) Set(val string) error {
+	if e.trimSpace {
+		val = strings.TrimSpace(val)
+	}
+	if len(e.choices) > 0 && !slices.Contains(e.choices, val) {
+		return &errInvalidChoice{value: val, choices: e.choices}
+	}
+	*e.destination = val
+	return nil
+}
+
+// checkDefault checks that the default value is one of the choices,
+// unless it is empty and so means no value
+func (e *enumValue) checkDefault() error {
+	val := *e.destination
+	if val == "" || len(e.choices) == 0 || slices.Contains(e.choices, val) {
+		return nil
+	}
+	return &errInvalidChoice{value: val, choices: e.choices}
+}
+
+func (e *enumValue) Get() any { return *e.destination }
+
+func (e *enumValue) String() string {
+	if e.destination != nil && *e.destination != "" {
+		return fmt.Sprintf("%q", *e.destination)
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnumFlag(t *testing.T) {
+	tests := []struct {
+		name          string
+		flag          Flag
+		arguments     []string
+		expectedValue string
+		expectedErr   string
+	}{
+		{
+			name: "valid",
+			flag: &EnumFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Config:  EnumConfig{Choices: []string{"json", "yaml", "table"}},
+			},
+			arguments:     []string{"--format", "yaml"},
+			expectedValue: "yaml",
+		},
+		{
+			name: "default",
+			flag: &EnumFlag{
+				Name:   "format",
+				Value:  "table",
+				Config: EnumConfig{Choices: []string{"json", "yaml", "table"}},
+			},
+			expectedValue: "table",
+		},
+		{
+			name: "trim space",
+			flag: &EnumFlag{
+				Name:   "format",
+				Config: EnumConfig{Choices: []string{"json", "yaml"}, TrimSpace: true},
+			},
+			arguments:     []string{"--format", " json "},
+			expectedValue: "json",
+		},
+		{
+			name: "invalid with suggestion",
+			flag: &EnumFlag{
+				Name:   "format",
+				Config: EnumConfig{Choices: []string{"json", "yaml", "table"}},
+			},
+			arguments:   []string{"--format", "jsno"},
+			expectedErr: `invalid value "jsno" for flag -format: value "jsno" is not one of "json", "yaml", "table". Did you mean "json"?`,
+		},
+		{
+			name: "invalid from env",
+			flag: &EnumFlag{
+				Name:    "format",
+				Config:  EnumConfig{Choices: []string{"json", "yaml"}},
+				Sources: EnvVars("APP_FORMAT"),
+			},
+			expectedErr: `could not parse "xml" as string value from environment variable "APP_FORMAT" for flag format`,
+		},
+		{
+			name: "invalid default",
+			flag: &EnumFlag{
+				Name:   "format",
+				Value:  "xml",
+				Config: EnumConfig{Choices: []string{"json"}},
+			},
+			arguments:   []string{"--format", "json"},
+			expectedErr: `invalid default value for flag format: value "xml" is not one of "json"`,
+		},
+	}
+
+	t.Setenv("APP_FORMAT", "xml")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &Command{
+				Name:      "mock",
+				Flags:     []Flag{tt.flag},
+				Writer:    io.Discard,
+				ErrWriter: io.Discard,
+			}
+
+			err := cmd.Run(buildTestContext(t), append([]string{"mock"}, tt.arguments...))
+
+			if tt.expectedErr != "" {
+				require.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			for _, name := range tt.flag.Names() {
+				assert.Equal(t, tt.expectedValue, cmd.String(name))
+			}
+		})
+	}
+}
+
+func TestEnumFlagHelpOutput(t *testing.T) {
+	fl := &EnumFlag{
+		Name:   "format",
+		Usage:  "output format",
+		Value:  "json",
+		Config: EnumConfig{Choices: []string{"json", "yaml", "table"}},
+	}
+
+	assert.Equal(t, `--format json|yaml|table	output format (default: "json")`, fl.String())
+
+	fl.Usage = "output `FMT`"
+	assert.Equal(t, `--format FMT	output FMT (default: "json")`, fl.String())
+}
+
+func TestEnumFlagShellCompletion(t *testing.T) {
+	out := &bytes.Buffer{}
+	cmd := &Command{
+		EnableShellCompletion: true,
+		Writer:                out,
+		Commands: []*Command{
+			{
+				Name: "show",
+				Flags: []Flag{
+					&EnumFlag{
+						Name:   "format",
+						Config: EnumConfig{Choices: []string{"json", "yaml", "table"}},
+					},
+					&StringFlag{Name: "filter"},
+				},
+				Action: func(ctx context.Context, cmd *Command) error { return nil },
+			},
+		},
+	}
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"foo", "show", "--format", completionFlag}))
+	assert.Equal(t, "json\nyaml\ntable\n", out.String())
+
+	out.Reset()
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"foo", "show", "--fil", completionFlag}))
+	assert.Equal(t, "--filter\n", out.String())
+}
+
+func TestEnumArg(t *testing.T) {
+	var dest string
+	cmd := &Command{
+		Name: "mock",
+		Arguments: []Argument{
+			&EnumArg{
+				Name:        "mode",
+				Destination: &dest,
+				Config:      EnumConfig{Choices: []string{"fast", "safe"}},
+			},
+		},
+		Writer:    io.Discard,
+		ErrWriter: io.Discard,
+		Action:    func(ctx context.Context, cmd *Command) error { return nil },
+	}
+
+	r := require.New(t)
+	r.NoError(cmd.Run(buildTestContext(t), []string{"mock", "safe"}))
+	r.Equal("safe", dest)
+	r.Equal("safe", cmd.StringArg("mode"))
+
+	err := cmd.Run(buildTestContext(t), []string{"mock", "fats"})
+	r.ErrorContains(err, `invalid value "fats" for argument mode: value "fats" is not one of "fast", "safe". Did you mean "fast"?`)
+
+	cmd.Arguments[0].(*EnumArg).Value = "xml"
+	err = cmd.Run(buildTestContext(t), []string{"mock"})
+	r.EqualError(err, `invalid default value for argument mode: value "xml" is not one of "fast", "safe"`)
+}
+
+func TestEnumValidateDefault(t *testing.T) {
+	cmd := &Command{
+		Name: "mock",
+		Flags: []Flag{
+			&EnumFlag{Name: "format", Value: "xml", Config: EnumConfig{Choices: []string{"json"}}},
+			&EnumFlag{Name: "color", Config: EnumConfig{Choices: []string{"auto", "never"}}},
+		},
+		Arguments: []Argument{
+			&EnumArg{Name: "mode", Value: "xml", Config: EnumConfig{Choices: []string{"fast", "safe"}}},
+		},
+	}
+
+	require.EqualError(t, cmd.Validate(), `command "mock": invalid default value for flag format: value "xml" is not one of "json"
+command "mock": invalid default value for argument mode: value "xml" is not one of "fast", "safe"`)
+}
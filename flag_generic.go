@@ -1,5 +1,8 @@
 package cli
 
+// GenericFlag is a flag setting a Value of any type. The Value is set in
+// place, so it keeps what a run set when the command is run again, see
+// Command.Run.
 type GenericFlag = FlagBase[Value, NoConfig, genericValue]
 
 // -- Value Value
@@ -5,7 +5,9 @@ import (
 	"flag"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,6 +29,19 @@ type multiValueParsingConfig struct {
 	DisableSliceFlagSeparator bool
 	// MapFlagKeyValueSeparator is used to customize the separator for MapFlag, the default is "="
 	MapFlagKeyValueSeparator string
+	// MergeSources is used to merge the values of the sources and the command line of the flag,
+	// an empty item dropping the items merged before it
+	MergeSources bool
+}
+
+// sourceMerger is implemented by the values of slice and map flags, which
+// can merge the values read from sources below the ones given on the
+// command line
+type sourceMerger interface {
+	// mergeSources merges the given values, from the lowest priority to
+	// the highest, between the default value and the items given on the
+	// command line
+	mergeSources(vals []string) error
 }
 
 type multiValueParsingConfigSetter interface {
@@ -73,13 +88,22 @@ type FlagBase[T any, C any, VC ValueCreator[T, C]] struct {
 	OnlyOnce         bool                                     `json:"onlyOnce"`         // whether this flag can be duplicated on the command line
 	Validator        func(T) error                            `json:"-"`                // custom function to validate this flag value
 	ValidateDefaults bool                                     `json:"validateDefaults"` // whether to validate defaults or not
+	ShellComplete    ValueCompleteFunc                        `json:"-"`                // function returning the candidate values of this flag for shell completion
+	NoEnvPrefix      bool                                     `json:"noEnvPrefix"`      // whether to not derive an environment variable from the EnvPrefix of the root command
+	MergeSources     bool                                     `json:"mergeSources"`     // whether a slice or map flag merges the values of all its sources and the command line, instead of using the first one found
+	OnChange         func(context.Context, *Command, T)       `json:"-"`                // callback called with the new value when the value of the sources changes, see Command.WatchSources
+	DefaultFunc      func() (T, error)                        `json:"-"`                // function computing the default value when the flag is neither given on the command line nor found in its sources, instead of Value
+
+	CompletionDirective CompletionDirective `json:"-"` // how the completion script should complete the value of this flag
 
 	// unexported fields for internal use
-	count      int   // number of times the flag has been set
-	hasBeenSet bool  // whether the flag has been set from env or file
-	applied    bool  // whether the flag has been applied to a flag set already
-	creator    VC    // value creator for this flag type
-	value      Value // value representing this flag's value
+	count      int           // number of times the flag has been set
+	hasBeenSet bool          // whether the flag has been set from env or file
+	applied    bool          // whether the flag has been applied to a flag set already
+	creator    VC            // value creator for this flag type
+	value      Value         // value representing this flag's value
+	source     ValueSource   // source the value has been read from, if any
+	mu         *sync.RWMutex // guards the value against the updates of WatchSources, see rlock
 }
 
 // GetValue returns the flags value as string representation and an empty
@@ -91,7 +115,13 @@ func (f *FlagBase[T, C, V]) GetValue() string {
 
 // TypeName returns the type of the flag.
 func (f *FlagBase[T, C, V]) TypeName() string {
-	ty := reflect.TypeOf(f.Value)
+	return typeName(reflect.TypeOf(f.Value))
+}
+
+// typeName returns the generic name of the type ty shown in help text,
+// such as int for all the sizes of integers. Slices are named after the
+// type of their elements and maps after the types of their keys and values.
+func typeName(ty reflect.Type) string {
 	if ty == nil {
 		return ""
 	}
@@ -129,8 +159,16 @@ func (f *FlagBase[T, C, V]) TypeName() string {
 func (f *FlagBase[T, C, V]) PostParse() error {
 	tracef("postparse (flag=%[1]q)", f.Name)
 
-	if !f.hasBeenSet {
-		if val, source, found := f.Sources.LookupWithSource(); found {
+	if m, ok := f.value.(sourceMerger); ok && f.MergeSources {
+		if err := f.mergeSources(m); err != nil {
+			return err
+		}
+	} else if !f.hasBeenSet {
+		val, source, found, err := f.Sources.LookupWithSourceE()
+		if err != nil {
+			return sourceError(f.Name, err)
+		}
+		if found {
 			// reflect.TypeOf yields nil when T is an interface type (e.g.
 			// GenericFlag) and the value is nil, so the kind has to be
 			// derived defensively.
@@ -151,9 +189,128 @@ func (f *FlagBase[T, C, V]) PostParse() error {
 			}
 
 			f.hasBeenSet = true
+			f.source = source
 		}
 	}
 
+	if !f.hasBeenSet && f.DefaultFunc != nil {
+		return f.computeDefault()
+	}
+
+	return nil
+}
+
+// computeDefault sets the value of the flag to the default returned by
+// DefaultFunc, checked by the Validator if ValidateDefaults is set
+func (f *FlagBase[T, C, V]) computeDefault() error {
+	tracef("computing default value (flag=%[1]q)", f.Name)
+
+	val, err := f.DefaultFunc()
+	if err != nil {
+		return fmt.Errorf("could not compute default value for flag %[1]s: %[2]w", f.Name, err)
+	}
+	if f.Validator != nil && f.ValidateDefaults {
+		if err := f.Validator(val); err != nil {
+			return err
+		}
+	}
+
+	dest := f.Destination
+	if dest == nil {
+		dest = new(T)
+	}
+	f.value = f.creator.Create(val, dest, f.Config)
+	return nil
+}
+
+// mergeSources merges the values of all the sources of the flag which
+// resolve below the items given on the command line
+func (f *FlagBase[T, C, V]) mergeSources(m sourceMerger) error {
+	vals, sources, err := f.Sources.lookupAll()
+	if err != nil {
+		return sourceError(f.Name, err)
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+
+	slices.Reverse(vals)
+	if err := m.mergeSources(vals); err != nil {
+		return fmt.Errorf("could not merge values from %[1]s for flag %[2]s: %[3]s", &f.Sources, f.Name, err)
+	}
+	if f.Validator != nil {
+		if err := f.Validator(f.value.Get().(T)); err != nil {
+			return err
+		}
+	}
+
+	if !f.hasBeenSet {
+		f.hasBeenSet = true
+		f.source = sources[0]
+	}
+	return nil
+}
+
+// pollSources reads the value of the flag from its sources again and
+// replaces the current one if it changed, unless the flag has been given
+// on the command line or has no OnChange callback. Flags of an interface
+// type, such as GenericFlag, can't be given a fresh value and so aren't
+// polled.
+func (f *FlagBase[T, C, V]) pollSources(ctx context.Context, cmd *Command) error {
+	if f.OnChange == nil || f.value == nil || (f.hasBeenSet && f.source == nil) {
+		return nil
+	}
+	if reflect.TypeFor[T]().Kind() == reflect.Interface {
+		return nil
+	}
+
+	vals, sources, err := f.Sources.lookupAll()
+	if err != nil {
+		return sourceError(f.Name, err)
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+
+	value := f.creator.Create(f.Value, new(T), f.Config)
+	if cf, ok := value.(multiValueParsingConfigSetter); ok {
+		c := cmd.multiValueParsingConfig()
+		c.MergeSources = f.MergeSources
+		cf.setMultiValueParsingConfig(c)
+	}
+	if m, ok := value.(sourceMerger); ok && f.MergeSources {
+		slices.Reverse(vals)
+		err = m.mergeSources(vals)
+	} else {
+		err = value.Set(vals[0])
+	}
+	if err != nil {
+		return fmt.Errorf("could not parse %[1]q as %[2]T value from %[3]s for flag %[4]s: %[5]s", vals[0], f.Value, sources[0], f.Name, err)
+	}
+
+	newVal := value.Get().(T)
+	if reflect.DeepEqual(newVal, f.value.Get()) {
+		return nil
+	}
+	if f.Validator != nil {
+		if err := f.Validator(newVal); err != nil {
+			return fmt.Errorf("invalid value from %[1]s for flag %[2]s: %[3]w", sources[0], f.Name, err)
+		}
+	}
+
+	tracef("value of flag changed in sources (flag=%[1]q)", f.Name)
+
+	f.mu.Lock()
+	if f.Destination != nil {
+		*f.Destination = newVal
+	} else {
+		f.value = value
+	}
+	f.hasBeenSet = true
+	f.source = sources[0]
+	f.mu.Unlock()
+
+	f.OnChange(ctx, cmd, newVal)
 	return nil
 }
 
@@ -161,21 +318,31 @@ func (f *FlagBase[T, C, V]) PostParse() error {
 func (f *FlagBase[T, C, V]) setMultiValueParsingConfig(c multiValueParsingConfig) {
 	tracef("setMultiValueParsingConfig %T, %+v", f.value, f.value)
 	if cf, ok := f.value.(multiValueParsingConfigSetter); ok {
+		c.MergeSources = f.MergeSources
 		cf.setMultiValueParsingConfig(c)
 	}
 }
 
 func (f *FlagBase[T, C, V]) PreParse() error {
 	newVal := f.Value
+	if f.mu == nil {
+		f.mu = &sync.RWMutex{}
+	}
 
 	if f.Destination == nil {
 		f.value = f.creator.Create(newVal, new(T), f.Config)
 	} else {
 		f.value = f.creator.Create(newVal, f.Destination, f.Config)
 	}
+	if dc, ok := f.value.(defaultChecker); ok {
+		if err := dc.checkDefault(); err != nil {
+			return fmt.Errorf("invalid default value for flag %s: %w", f.Name, err)
+		}
+	}
 
-	// Validate the given default or values set from external sources as well
-	if f.Validator != nil && f.ValidateDefaults {
+	// Validate the given default or values set from external sources as well,
+	// a computed default being validated once computed
+	if f.Validator != nil && f.ValidateDefaults && f.DefaultFunc == nil {
 		if err := f.Validator(f.value.Get().(T)); err != nil {
 			return err
 		}
@@ -219,6 +386,8 @@ func (f *FlagBase[T, C, V]) Set(_ string, val string) error {
 }
 
 func (f *FlagBase[T, C, V]) Get() any {
+	defer f.rlock()()
+
 	if f.value != nil {
 		return f.value.Get()
 	}
@@ -237,9 +406,22 @@ func (f *FlagBase[T, C, V]) String() string {
 
 // IsSet returns whether or not the flag has been set through env or file
 func (f *FlagBase[T, C, V]) IsSet() bool {
+	defer f.rlock()()
+
 	return f.hasBeenSet
 }
 
+// rlock locks the value of the flag for reading, so that it isn't read
+// while being replaced by WatchSources, and returns the function unlocking
+// it
+func (f *FlagBase[T, C, V]) rlock() func() {
+	if f.mu == nil {
+		return func() {}
+	}
+	f.mu.RLock()
+	return f.mu.RUnlock
+}
+
 // Names returns the names of the flag
 func (f *FlagBase[T, C, V]) Names() []string {
 	return FlagNames(f.Name, f.Aliases)
@@ -282,9 +464,35 @@ func (f *FlagBase[T, C, V]) TakesValue() bool {
 
 // GetDefaultText returns the default text for this flag
 func (f *FlagBase[T, C, V]) GetDefaultText() string {
+	if f.DefaultText == "" && f.DefaultFunc != nil {
+		return computedDefaultText
+	}
 	return f.DefaultText
 }
 
+// GetChoices returns the allowed values for this flag, if any
+func (f *FlagBase[T, C, V]) GetChoices() []string {
+	return configChoices(f.Config)
+}
+
+// CompleteValue returns the candidate values of this flag for shell
+// completion, which are the allowed values of the flag unless a
+// ShellComplete function is set
+func (f *FlagBase[T, C, V]) CompleteValue(ctx context.Context, cmd *Command) []string {
+	if f.ShellComplete != nil {
+		return f.ShellComplete(ctx, cmd)
+	}
+	return f.GetChoices()
+}
+
+// GetCompletionDirective returns how the completion script should complete
+// the value of this flag
+func (f *FlagBase[T, C, V]) GetCompletionDirective() CompletionDirective {
+	d := f.CompletionDirective
+	d.Files = d.Files || f.TakesFile
+	return d
+}
+
 // RunAction executes flag action if set
 func (f *FlagBase[T, C, V]) RunAction(ctx context.Context, cmd *Command) error {
 	if f.Action != nil {
@@ -352,11 +560,86 @@ func (f *FlagBase[T, C, VC]) IsMultiValueFlag() bool {
 	return kind == reflect.Slice || kind == reflect.Map
 }
 
+// reset restores the flag to the state it had before being parsed
+func (f *FlagBase[T, C, VC]) reset() {
+	f.count = 0
+	f.hasBeenSet = false
+	f.applied = false
+	f.value = nil
+	f.source = nil
+	f.mu = nil
+}
+
+// clone returns a copy of the flag without the state of a previous run
+func (f *FlagBase[T, C, VC]) clone() Flag {
+	c := *f
+	c.Aliases = slices.Clone(f.Aliases)
+	c.Sources.Chain = slices.Clone(f.Sources.Chain)
+	c.reset()
+	return &c
+}
+
+// defaultChecker is implemented by values which only accept some values,
+// such as the choices of an enum, to check the default they were created
+// with
+type defaultChecker interface {
+	checkDefault() error
+}
+
+// validateDefinition checks the default value against the values the
+// flag accepts, and against the Validator unless the flag is required and
+// so never left to its default, the default is computed, or the default
+// is the zero value and ValidateDefaults isn't set
+func (f *FlagBase[T, C, VC]) validateDefinition() error {
+	if dc, ok := f.creator.Create(f.Value, new(T), f.Config).(defaultChecker); ok {
+		if err := dc.checkDefault(); err != nil {
+			return fmt.Errorf("invalid default value for flag %s: %w", f.Name, err)
+		}
+	}
+	if f.Validator == nil || f.Required || f.DefaultFunc != nil {
+		return nil
+	}
+	if !f.ValidateDefaults && reflect.ValueOf(&f.Value).Elem().IsZero() {
+		return nil
+	}
+	if err := f.Validator(f.Value); err != nil {
+		return fmt.Errorf("invalid default value for flag %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// deriveEnvVar adds the given environment variable to the sources of the
+// flag unless it already reads one or opts out
+func (f *FlagBase[T, C, VC]) deriveEnvVar(key string) {
+	if f.NoEnvPrefix || len(f.Sources.EnvKeys()) > 0 {
+		return
+	}
+	f.Sources.Append(EnvVars(key))
+}
+
+// valueSources returns the sources the flag reads its value from
+func (f *FlagBase[T, C, VC]) valueSources() ValueSourceChain {
+	return f.Sources
+}
+
+// valueSource returns the source the value of the flag has been read from,
+// or nil if it has been given on the command line or not at all
+func (f *FlagBase[T, C, VC]) valueSource() ValueSource {
+	defer f.rlock()()
+
+	return f.source
+}
+
 // IsLocal returns false if flag needs to be persistent across subcommands
 func (f *FlagBase[T, C, VC]) IsLocal() bool {
 	return f.Local
 }
 
+// IsOnlyOnce returns true if the flag can only be given once
+func (f *FlagBase[T, C, VC]) IsOnlyOnce() bool {
+	return f.OnlyOnce
+}
+
 // IsBoolFlag returns whether the flag doesn't need to accept args
 func (f *FlagBase[T, C, VC]) IsBoolFlag() bool {
 	bf, ok := f.value.(boolFlag)
@@ -3,6 +3,7 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"reflect"
 	"sort"
 	"strings"
@@ -14,6 +15,8 @@ type MapBase[T any, C any, VC ValueCreator[T, C]] struct {
 	hasBeenSet       bool
 	value            Value
 	multiValueConfig multiValueParsingConfig
+	defaults         map[string]T
+	cleared          bool
 }
 
 func (i MapBase[T, C, VC]) Create(val map[string]T, p *map[string]T, c C) Value {
@@ -25,8 +28,9 @@ func (i MapBase[T, C, VC]) Create(val map[string]T, p *map[string]T, c C) Value
 	np := new(T)
 	var vc VC
 	return &MapBase[T, C, VC]{
-		dict:  p,
-		value: vc.Create(t, np, c),
+		dict:     p,
+		value:    vc.Create(t, np, c),
+		defaults: maps.Clone(val),
 	}
 }
 
@@ -63,6 +67,13 @@ func (i *MapBase[T, C, VC]) Set(value string) error {
 		return nil
 	}
 
+	return i.setItems(value)
+}
+
+// setItems parses the value and sets its items in the mapping of values.
+// When merging sources, an empty value or a leading empty item drops the
+// items before it, while other empty items are ignored.
+func (i *MapBase[T, C, VC]) setItems(value string) error {
 	mvc := &i.multiValueConfig
 	keyValueSeparator := mvc.MapFlagKeyValueSeparator
 	if len(keyValueSeparator) == 0 {
@@ -76,7 +87,14 @@ func (i *MapBase[T, C, VC]) Set(value string) error {
 		mvc.SliceFlagSeparator,
 		mvc.DisableSliceFlagSeparator,
 	)
-	for _, item := range flagSplitMultiValues(value, mvc.SliceFlagSeparator, mvc.DisableSliceFlagSeparator) {
+	for n, item := range flagSplitMultiValues(value, mvc.SliceFlagSeparator, mvc.DisableSliceFlagSeparator) {
+		if mvc.MergeSources && item == "" {
+			if n == 0 {
+				*i.dict = map[string]T{}
+				i.cleared = true
+			}
+			continue
+		}
 		key, value, ok := strings.Cut(item, keyValueSeparator)
 		if !ok {
 			return fmt.Errorf("item %q is missing separator %q", item, keyValueSeparator)
@@ -90,6 +108,34 @@ func (i *MapBase[T, C, VC]) Set(value string) error {
 	return nil
 }
 
+// mergeSources sets the items of the values read from sources over the
+// default items and under the ones given on the command line, unless
+// those dropped the items before them
+func (i *MapBase[T, C, VC]) mergeSources(vals []string) error {
+	if i.cleared {
+		return nil
+	}
+
+	var given map[string]T
+	if i.hasBeenSet {
+		given = *i.dict
+	}
+
+	*i.dict = maps.Clone(i.defaults)
+	if *i.dict == nil {
+		*i.dict = map[string]T{}
+	}
+	i.hasBeenSet = true
+	for _, val := range vals {
+		if err := i.setItems(val); err != nil {
+			return err
+		}
+	}
+	maps.Copy(*i.dict, given)
+
+	return nil
+}
+
 // String returns a readable representation of this value (for usage defaults)
 func (i *MapBase[T, C, VC]) String() string {
 	v := i.Value()
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 )
 
@@ -14,6 +15,9 @@ type SliceBase[T any, C any, VC ValueCreator[T, C]] struct {
 	value                 Value
 	sliceSeparator        string
 	disableSliceSeparator bool
+	merge                 bool
+	defaults              []T
+	cleared               bool
 }
 
 func (i SliceBase[T, C, VC]) Create(val []T, p *[]T, c C) Value {
@@ -23,8 +27,9 @@ func (i SliceBase[T, C, VC]) Create(val []T, p *[]T, c C) Value {
 	np := new(T)
 	var vc VC
 	return &SliceBase[T, C, VC]{
-		slice: p,
-		value: vc.Create(t, np, c),
+		slice:    p,
+		value:    vc.Create(t, np, c),
+		defaults: slices.Clone(val),
 	}
 }
 
@@ -39,6 +44,7 @@ func NewSliceBase[T any, C any, VC ValueCreator[T, C]](defaults ...T) *SliceBase
 func (i *SliceBase[T, C, VC]) setMultiValueParsingConfig(c multiValueParsingConfig) {
 	i.disableSliceSeparator = c.DisableSliceFlagSeparator
 	i.sliceSeparator = c.SliceFlagSeparator
+	i.merge = c.MergeSources
 	tracef("set slice parsing config - slice separator '%s', disable separator:%v", i.sliceSeparator, i.disableSliceSeparator)
 }
 
@@ -56,6 +62,13 @@ func (i *SliceBase[T, C, VC]) Set(value string) error {
 		return nil
 	}
 
+	return i.appendItems(value)
+}
+
+// appendItems parses the value and appends its items to the list of
+// values. When merging sources, an empty value or a leading empty item
+// drops the items before it, while other empty items are ignored.
+func (i *SliceBase[T, C, VC]) appendItems(value string) error {
 	trimSpace := true
 	// hack. How do we know if we should trim spaces?
 	// it makes sense only for string slice flags which have
@@ -67,10 +80,17 @@ func (i *SliceBase[T, C, VC]) Set(value string) error {
 	}
 
 	tracef("splitting slice value '%s', separator '%s', disable separator:%v", value, i.sliceSeparator, i.disableSliceSeparator)
-	for _, s := range flagSplitMultiValues(value, i.sliceSeparator, i.disableSliceSeparator) {
+	for n, s := range flagSplitMultiValues(value, i.sliceSeparator, i.disableSliceSeparator) {
 		if trimSpace {
 			s = strings.TrimSpace(s)
 		}
+		if i.merge && s == "" {
+			if n == 0 {
+				*i.slice = []T{}
+				i.cleared = true
+			}
+			continue
+		}
 		if err := i.value.Set(s); err != nil {
 			return err
 		}
@@ -80,6 +100,31 @@ func (i *SliceBase[T, C, VC]) Set(value string) error {
 	return nil
 }
 
+// mergeSources puts the items of the values read from sources between the
+// default items and the ones given on the command line, unless those
+// dropped the items before them
+func (i *SliceBase[T, C, VC]) mergeSources(vals []string) error {
+	if i.cleared {
+		return nil
+	}
+
+	var given []T
+	if i.hasBeenSet {
+		given = *i.slice
+	}
+
+	*i.slice = append([]T{}, i.defaults...)
+	i.hasBeenSet = true
+	for _, val := range vals {
+		if err := i.appendItems(val); err != nil {
+			return err
+		}
+	}
+	*i.slice = append(*i.slice, given...)
+
+	return nil
+}
+
 // String returns a readable representation of this value (for usage defaults)
 func (i *SliceBase[T, C, VC]) String() string {
 	var defaultVals []string
@@ -0,0 +1,271 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FlagsFromStruct derives a set of flags from the exported fields of the
+// struct pointed to by v. Each flag uses its field as Destination and the
+// field's current value as the default, so the struct holds the parsed
+// values once the command has been run.
+//
+// Fields are configured with struct tags:
+//
+//	cli:"name,alias,..."  flag name and aliases, "-" skips the field
+//	env:"VAR,..."         environment variables to read the value from
+//	usage:"..."           usage text for help output
+//	required:"true"       whether the flag is required
+//	hidden:"true"         whether to hide the flag in help output
+//	local:"true"          whether the flag is local to the command
+//	category:"..."        category of the flag
+//	layout:"..."          time layout of a time.Time field, time.RFC3339
+//	                      by default
+//
+// A field without a cli tag is named after the field in kebab case, e.g.
+// DryRun becomes --dry-run. Fields of nested struct type contribute their
+// own flags, placed in a category named by the category tag or else the
+// field name. Fields of embedded struct type are flattened.
+//
+// Fields whose pointer implements Value are bound to a GenericFlag, and
+// fields of type time.Time to a TimestampFlag.
+func FlagsFromStruct(v any) ([]Flag, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a non-nil pointer to a struct, got %T", v)
+	}
+	return flagsFromStruct(rv.Elem(), "")
+}
+
+func flagsFromStruct(rv reflect.Value, category string) ([]Flag, error) {
+	var flags []Flag
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+		// the exported fields of an embedded struct are promoted even
+		// when the struct type itself is unexported
+		if !field.IsExported() && !(field.Anonymous && fv.Kind() == reflect.Struct) {
+			continue
+		}
+
+		tag := field.Tag.Get("cli")
+		if tag == "-" {
+			continue
+		}
+
+		if isNestedStructField(fv) {
+			nestedCategory := category
+			if !field.Anonymous {
+				nestedCategory = field.Tag.Get("category")
+				if nestedCategory == "" {
+					nestedCategory = field.Name
+				}
+			}
+			nested, err := flagsFromStruct(fv, nestedCategory)
+			if err != nil {
+				return nil, err
+			}
+			flags = append(flags, nested...)
+			continue
+		}
+
+		opts, err := newStructFlagOptions(field, category)
+		if err != nil {
+			return nil, err
+		}
+
+		fl := newStructFlag(fv.Addr().Interface(), opts)
+		if fl == nil {
+			return nil, fmt.Errorf("field %s has unsupported type %s", field.Name, field.Type)
+		}
+		flags = append(flags, fl)
+	}
+
+	return flags, nil
+}
+
+// isNestedStructField reports whether a field should contribute its own
+// fields as flags rather than being bound to a single flag
+func isNestedStructField(fv reflect.Value) bool {
+	if fv.Kind() != reflect.Struct {
+		return false
+	}
+	if reflect.PointerTo(fv.Type()).Implements(reflect.TypeOf((*Value)(nil)).Elem()) {
+		return false
+	}
+	return fv.Type() != reflect.TypeOf(time.Time{})
+}
+
+// structFlagOptions holds the tag derived settings common to every flag
+// generated by FlagsFromStruct
+type structFlagOptions struct {
+	name     string
+	aliases  []string
+	usage    string
+	category string
+	sources  ValueSourceChain
+	required bool
+	hidden   bool
+	local    bool
+	layout   string
+}
+
+func newStructFlagOptions(field reflect.StructField, category string) (structFlagOptions, error) {
+	opts := structFlagOptions{
+		name:     kebabCase(field.Name),
+		usage:    field.Tag.Get("usage"),
+		category: category,
+		layout:   time.RFC3339,
+	}
+
+	if tag := field.Tag.Get("cli"); tag != "" {
+		names := strings.Split(tag, ",")
+		for i := range names {
+			names[i] = strings.TrimSpace(names[i])
+		}
+		if names[0] != "" {
+			opts.name = names[0]
+		}
+		opts.aliases = names[1:]
+	}
+
+	if tag := field.Tag.Get("category"); tag != "" {
+		opts.category = tag
+	}
+
+	if tag := field.Tag.Get("layout"); tag != "" {
+		opts.layout = tag
+	}
+
+	if tag := field.Tag.Get("env"); tag != "" {
+		opts.sources = EnvVars(strings.Split(tag, ",")...)
+	}
+
+	for _, b := range []struct {
+		key  string
+		dest *bool
+	}{
+		{"required", &opts.required},
+		{"hidden", &opts.hidden},
+		{"local", &opts.local},
+	} {
+		tag, ok := field.Tag.Lookup(b.key)
+		if !ok {
+			continue
+		}
+		val, err := strconv.ParseBool(tag)
+		if err != nil {
+			return opts, fmt.Errorf("field %s has invalid %s tag %q: %w", field.Name, b.key, tag, err)
+		}
+		*b.dest = val
+	}
+
+	return opts, nil
+}
+
+// newStructFlag returns a flag bound to the field pointed to by p, or nil
+// if there is no flag type for the field
+func newStructFlag(p any, opts structFlagOptions) Flag {
+	switch p := p.(type) {
+	case Value:
+		return &GenericFlag{
+			Name:     opts.name,
+			Aliases:  opts.aliases,
+			Usage:    opts.usage,
+			Category: opts.category,
+			Sources:  opts.sources,
+			Required: opts.required,
+			Hidden:   opts.hidden,
+			Local:    opts.local,
+			Value:    p,
+		}
+	case *string:
+		return bindStructFlag[string, StringConfig, stringValue](p, opts)
+	case *bool:
+		return bindStructFlag[bool, BoolConfig, boolValue](p, opts)
+	case *int:
+		return bindStructFlag[int, IntegerConfig, intValue[int]](p, opts)
+	case *int8:
+		return bindStructFlag[int8, IntegerConfig, intValue[int8]](p, opts)
+	case *int16:
+		return bindStructFlag[int16, IntegerConfig, intValue[int16]](p, opts)
+	case *int32:
+		return bindStructFlag[int32, IntegerConfig, intValue[int32]](p, opts)
+	case *int64:
+		return bindStructFlag[int64, IntegerConfig, intValue[int64]](p, opts)
+	case *uint:
+		return bindStructFlag[uint, IntegerConfig, uintValue[uint]](p, opts)
+	case *uint8:
+		return bindStructFlag[uint8, IntegerConfig, uintValue[uint8]](p, opts)
+	case *uint16:
+		return bindStructFlag[uint16, IntegerConfig, uintValue[uint16]](p, opts)
+	case *uint32:
+		return bindStructFlag[uint32, IntegerConfig, uintValue[uint32]](p, opts)
+	case *uint64:
+		return bindStructFlag[uint64, IntegerConfig, uintValue[uint64]](p, opts)
+	case *float32:
+		return bindStructFlag[float32, NoConfig, floatValue[float32]](p, opts)
+	case *float64:
+		return bindStructFlag[float64, NoConfig, floatValue[float64]](p, opts)
+	case *time.Duration:
+		return bindStructFlag[time.Duration, NoConfig, durationValue](p, opts)
+	case *time.Time:
+		fl := bindStructFlag[time.Time, TimestampConfig, timestampValue](p, opts)
+		fl.Config.Layouts = []string{opts.layout}
+		return fl
+	case *[]string:
+		return bindStructFlag[[]string, StringConfig, StringSlice](p, opts)
+	case *[]int:
+		return bindStructFlag[[]int, IntegerConfig, IntSlice](p, opts)
+	case *[]int64:
+		return bindStructFlag[[]int64, IntegerConfig, Int64Slice](p, opts)
+	case *[]uint:
+		return bindStructFlag[[]uint, IntegerConfig, UintSlice](p, opts)
+	case *[]uint64:
+		return bindStructFlag[[]uint64, IntegerConfig, Uint64Slice](p, opts)
+	case *[]float64:
+		return bindStructFlag[[]float64, NoConfig, Float64Slice](p, opts)
+	case *map[string]string:
+		return bindStructFlag[map[string]string, StringConfig, StringMap](p, opts)
+	}
+	return nil
+}
+
+func bindStructFlag[T any, C any, VC ValueCreator[T, C]](p *T, opts structFlagOptions) *FlagBase[T, C, VC] {
+	return &FlagBase[T, C, VC]{
+		Name:        opts.name,
+		Aliases:     opts.aliases,
+		Usage:       opts.usage,
+		Category:    opts.category,
+		Sources:     opts.sources,
+		Required:    opts.required,
+		Hidden:      opts.hidden,
+		Local:       opts.local,
+		Value:       *p,
+		Destination: p,
+	}
+}
+
+// kebabCase converts a Go identifier such as DryRun or HTTPPort into a
+// flag name such as dry-run or http-port
+func kebabCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testStructLevel struct {
+	level string
+}
+
+func (l *testStructLevel) Set(s string) error { l.level = strings.ToUpper(s); return nil }
+func (l *testStructLevel) Get() any           { return l.level }
+func (l *testStructLevel) String() string     { return l.level }
+
+type testStructCommon struct {
+	Verbose bool `cli:"verbose,V" usage:"verbose output"`
+}
+
+type testStructOptions struct {
+	testStructCommon
+	Name     string            `cli:"name,n" env:"TEST_STRUCT_NAME" usage:"the name" required:"true"`
+	DryRun   bool              `usage:"do nothing"`
+	HTTPPort int               `usage:"port to listen on"`
+	Timeout  time.Duration     `cli:"timeout"`
+	Tags     []string          `cli:"tag"`
+	Labels   map[string]string `cli:"label"`
+	Level    testStructLevel   `cli:"level"`
+	Ignored  string            `cli:"-"`
+
+	Database struct {
+		URL      string `cli:"db-url"`
+		PoolSize int64  `cli:"db-pool-size"`
+	}
+	Cache struct {
+		TTL uint `cli:"cache-ttl"`
+	} `category:"Caching"`
+}
+
+func TestFlagsFromStruct(t *testing.T) {
+	t.Setenv("TEST_STRUCT_NAME", "from-env")
+
+	opts := &testStructOptions{HTTPPort: 8080}
+	opts.Database.PoolSize = 4
+
+	flags, err := FlagsFromStruct(opts)
+	require.NoError(t, err)
+
+	var names []string
+	for _, fl := range flags {
+		names = append(names, fl.Names()[0])
+	}
+	assert.Equal(t, []string{
+		"verbose", "name", "dry-run", "http-port", "timeout", "tag", "label", "level",
+		"db-url", "db-pool-size", "cache-ttl",
+	}, names)
+
+	name := flags[1].(*StringFlag)
+	assert.Equal(t, []string{"n"}, name.Aliases)
+	assert.Equal(t, "the name", name.Usage)
+	assert.True(t, name.Required)
+	assert.Equal(t, []string{"TEST_STRUCT_NAME"}, name.GetEnvVars())
+
+	assert.Equal(t, "", flags[0].(CategorizableFlag).GetCategory())
+	assert.Equal(t, "Database", flags[8].(CategorizableFlag).GetCategory())
+	assert.Equal(t, "Caching", flags[10].(CategorizableFlag).GetCategory())
+
+	cmd := &Command{
+		Name:   "mock",
+		Flags:  flags,
+		Writer: io.Discard,
+		Action: func(context.Context, *Command) error { return nil },
+	}
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{
+		"mock", "-V", "--dry-run", "--timeout", "5s", "--tag", "a", "--tag", "b",
+		"--label", "k=v", "--level", "debug", "--db-url", "postgres://db",
+	}))
+
+	assert.True(t, opts.Verbose)
+	assert.Equal(t, "from-env", opts.Name)
+	assert.True(t, opts.DryRun)
+	assert.Equal(t, 8080, opts.HTTPPort)
+	assert.Equal(t, 5*time.Second, opts.Timeout)
+	assert.Equal(t, []string{"a", "b"}, opts.Tags)
+	assert.Equal(t, map[string]string{"k": "v"}, opts.Labels)
+	assert.Equal(t, "DEBUG", opts.Level.level)
+	assert.Equal(t, "postgres://db", opts.Database.URL)
+	assert.Equal(t, int64(4), opts.Database.PoolSize)
+}
+
+func TestFlagsFromStructTimestamp(t *testing.T) {
+	opts := &struct {
+		Since time.Time
+		Until time.Time `layout:"2006-01-02"`
+	}{}
+
+	flags, err := FlagsFromStruct(opts)
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+	assert.IsType(t, &TimestampFlag{}, flags[0])
+
+	cmd := &Command{Name: "mock", Flags: flags}
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{
+		"mock", "--since", "2024-03-01T10:00:00Z", "--until", "2024-03-31",
+	}))
+
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), opts.Since)
+	assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), opts.Until)
+
+	err = cmd.Run(buildTestContext(t), []string{"mock", "--until", "31/03/2024"})
+	assert.ErrorContains(t, err, "invalid value \"31/03/2024\" for flag -until")
+}
+
+func TestFlagsFromStructHelpCategories(t *testing.T) {
+	opts := &testStructOptions{}
+	flags, err := FlagsFromStruct(opts)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	cmd := &Command{Name: "mock", Flags: flags, Writer: out}
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"mock", "--help"}))
+
+	assert.Contains(t, out.String(), "Caching\n\n   --cache-ttl uint")
+	assert.Contains(t, out.String(), "Database\n\n   --db-pool-size int")
+}
+
+func TestFlagsFromStructErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		v           any
+		expectedErr string
+	}{
+		{
+			name:        "not a pointer",
+			v:           testStructOptions{},
+			expectedErr: "expected a non-nil pointer to a struct, got cli.testStructOptions",
+		},
+		{
+			name:        "nil pointer",
+			v:           (*testStructOptions)(nil),
+			expectedErr: "expected a non-nil pointer to a struct, got *cli.testStructOptions",
+		},
+		{
+			name: "unsupported type",
+			v: &struct {
+				Ch chan int
+			}{},
+			expectedErr: "field Ch has unsupported type chan int",
+		},
+		{
+			name: "invalid bool tag",
+			v: &struct {
+				Name string `required:"yes please"`
+			}{},
+			expectedErr: `field Name has invalid required tag "yes please"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FlagsFromStruct(tt.v)
+			require.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	for in, expected := range map[string]string{
+		"Name":       "name",
+		"DryRun":     "dry-run",
+		"HTTPPort":   "http-port",
+		"ServerURL":  "server-url",
+		"Level2Deep": "level2-deep",
+	} {
+		assert.Equal(t, expected, kebabCase(in), in)
+	}
+}
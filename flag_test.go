@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"flag"
@@ -873,6 +874,295 @@ func TestStringSliceFlagValueFromCommand(t *testing.T) {
 	require.Equal(t, []string{"a", "b", "c"}, cmd.StringSlice(f.Name))
 }
 
+func TestSliceFlagMergeSources(t *testing.T) {
+	tests := []struct {
+		name     string
+		merge    bool
+		env      string
+		config   string
+		args     []string
+		expected []string
+		origin   string
+	}{
+		{
+			name:     "first source without merging",
+			env:      "e1,e2",
+			config:   "c1",
+			expected: []string{"e1", "e2"},
+			origin:   `environment variable "APP_TAGS"`,
+		},
+		{
+			name:     "command line without merging",
+			env:      "e1",
+			args:     []string{"--tag", "a1"},
+			expected: []string{"a1"},
+			origin:   "command line",
+		},
+		{
+			name:     "defaults and sources",
+			merge:    true,
+			env:      "e1,e2",
+			config:   "c1",
+			expected: []string{"d1", "c1", "e1", "e2"},
+			origin:   `environment variable "APP_TAGS"`,
+		},
+		{
+			name:     "command line last",
+			merge:    true,
+			env:      "e1",
+			config:   "c1",
+			args:     []string{"--tag", "a1", "--tag", "a2"},
+			expected: []string{"d1", "c1", "e1", "a1", "a2"},
+			origin:   "command line",
+		},
+		{
+			name:     "reset on the command line",
+			merge:    true,
+			env:      "e1",
+			config:   "c1",
+			args:     []string{"--tag", "a1", "--tag=", "--tag", "a2"},
+			expected: []string{"a2"},
+			origin:   "command line",
+		},
+		{
+			name:     "reset in a source",
+			merge:    true,
+			env:      ",e1",
+			config:   "c1",
+			args:     []string{"--tag", "a1"},
+			expected: []string{"e1", "a1"},
+			origin:   "command line",
+		},
+		{
+			name:     "trailing comma",
+			merge:    true,
+			env:      "e1",
+			args:     []string{"--tag", "c,"},
+			expected: []string{"d1", "e1", "c"},
+			origin:   "command line",
+		},
+		{
+			name:     "double comma",
+			merge:    true,
+			env:      "e1,,e2",
+			args:     []string{"--tag", "a,,b"},
+			expected: []string{"d1", "e1", "e2", "a", "b"},
+			origin:   "command line",
+		},
+		{
+			name:     "no sources",
+			merge:    true,
+			expected: []string{"d1"},
+			origin:   "default",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv("APP_TAGS", test.env)
+			}
+			sources := EnvVars("APP_TAGS")
+			if test.config != "" {
+				sources.Chain = append(sources.Chain, NewMapValueSource("tags", NewMapSource("config", map[any]any{"tags": test.config})))
+			}
+
+			cmd := &Command{
+				Flags: []Flag{
+					&StringSliceFlag{
+						Name:         "tag",
+						Value:        []string{"d1"},
+						Sources:      sources,
+						MergeSources: test.merge,
+					},
+				},
+			}
+
+			require.NoError(t, cmd.Run(buildTestContext(t), append([]string{"app"}, test.args...)))
+			assert.Equal(t, test.expected, cmd.StringSlice("tag"))
+			assert.Equal(t, test.origin, cmd.Provenance("tag").String())
+		})
+	}
+}
+
+func TestMapFlagMergeSources(t *testing.T) {
+	t.Setenv("APP_LABELS", "env=prod,region=eu")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected map[string]string
+	}{
+		{
+			name:     "sources",
+			expected: map[string]string{"owner": "ops", "team": "core", "env": "prod", "region": "eu"},
+		},
+		{
+			name:     "command line overrides",
+			args:     []string{"--label", "region=us,tier=gold"},
+			expected: map[string]string{"owner": "ops", "team": "core", "env": "prod", "region": "us", "tier": "gold"},
+		},
+		{
+			name:     "reset on the command line",
+			args:     []string{"--label", ",tier=gold"},
+			expected: map[string]string{"tier": "gold"},
+		},
+		{
+			name:     "reset with an empty value",
+			args:     []string{"--label", "", "--label", "tier=gold"},
+			expected: map[string]string{"tier": "gold"},
+		},
+		{
+			name:     "trailing comma",
+			args:     []string{"--label", "tier=gold,"},
+			expected: map[string]string{"owner": "ops", "team": "core", "env": "prod", "region": "eu", "tier": "gold"},
+		},
+		{
+			name:     "double comma",
+			args:     []string{"--label", "tier=gold,,region=us"},
+			expected: map[string]string{"owner": "ops", "team": "core", "env": "prod", "region": "us", "tier": "gold"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewMapSource("config", map[any]any{"labels": "team=core,env=dev"})
+			cmd := &Command{
+				Flags: []Flag{
+					&StringMapFlag{
+						Name:         "label",
+						Value:        map[string]string{"owner": "ops", "team": "none"},
+						Sources:      NewValueSourceChain(EnvVar("APP_LABELS"), NewMapValueSource("labels", config)),
+						MergeSources: true,
+					},
+				},
+			}
+
+			require.NoError(t, cmd.Run(buildTestContext(t), append([]string{"app"}, test.args...)))
+			assert.Equal(t, test.expected, cmd.StringMap("label"))
+		})
+	}
+
+	t.Run("invalid source item", func(t *testing.T) {
+		t.Setenv("APP_LABELS", "env")
+		cmd := &Command{
+			Flags: []Flag{
+				&StringMapFlag{Name: "label", Sources: EnvVars("APP_LABELS"), MergeSources: true},
+			},
+		}
+
+		err := cmd.Run(buildTestContext(t), []string{"app"})
+		assert.EqualError(t, err, `could not merge values from environment variable "APP_LABELS" for flag label: item "env" is missing separator "="`)
+	})
+}
+
+func TestFlagDefaultFunc(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		validate bool
+		funcErr  error
+		expected int
+		calls    int
+		err      string
+	}{
+		{
+			name:     "computed",
+			expected: 8,
+			calls:    1,
+		},
+		{
+			name:     "command line",
+			args:     []string{"--jobs", "2"},
+			expected: 2,
+		},
+		{
+			name:     "source",
+			env:      "3",
+			expected: 3,
+		},
+		{
+			name:    "error",
+			funcErr: errors.New("no cpu info"),
+			calls:   1,
+			err:     "could not compute default value for flag jobs: no cpu info",
+		},
+		{
+			name:     "validated",
+			validate: true,
+			calls:    1,
+			err:      "at most 4 jobs",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv("APP_JOBS", test.env)
+			}
+
+			calls := 0
+			var jobs int
+			cmd := &Command{
+				Flags: []Flag{
+					&IntFlag{
+						Name:        "jobs",
+						Value:       1,
+						Destination: &jobs,
+						Sources:     EnvVars("APP_JOBS"),
+						DefaultFunc: func() (int, error) {
+							calls++
+							return 8, test.funcErr
+						},
+						Validator: func(i int) error {
+							if i > 4 {
+								return errors.New("at most 4 jobs")
+							}
+							return nil
+						},
+						ValidateDefaults: test.validate,
+					},
+				},
+			}
+
+			err := cmd.Run(buildTestContext(t), append([]string{"app"}, test.args...))
+			assert.Equal(t, test.calls, calls)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, cmd.Int("jobs"))
+			assert.Equal(t, test.expected, jobs)
+			assert.Equal(t, test.calls == 0, cmd.IsSet("jobs"))
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := &Command{
+			Writer: &out,
+			Flags: []Flag{
+				&StringFlag{
+					Name:        "branch",
+					DefaultFunc: func() (string, error) { panic("must not be called") },
+				},
+				&StringFlag{
+					Name:        "cache-dir",
+					DefaultText: "$XDG_CACHE_HOME/app",
+					DefaultFunc: func() (string, error) { panic("must not be called") },
+				},
+			},
+		}
+
+		require.NoError(t, cmd.Validate())
+		require.NoError(t, cmd.Run(buildTestContext(t), []string{"app", "--help"}))
+		assert.Contains(t, out.String(), "--branch string     (default: computed)")
+		assert.Contains(t, out.String(), "--cache-dir string  (default: $XDG_CACHE_HOME/app)")
+	})
+}
+
 var intFlagTests = []struct {
 	name     string
 	expected string
@@ -5,6 +5,10 @@ import "context"
 // ShellCompleteFunc is an action to execute when the shell completion flag is set
 type ShellCompleteFunc func(context.Context, *Command)
 
+// ValueCompleteFunc returns the candidate values of a flag or argument
+// when the shell completion flag is set
+type ValueCompleteFunc func(context.Context, *Command) []string
+
 // BeforeFunc is an action that executes prior to any subcommands being run once
 // the context is ready.  If a non-nil error is returned, no subcommands are
 // run.
@@ -184,17 +184,19 @@ func DefaultRootCommandComplete(ctx context.Context, cmd *Command) {
 // DefaultAppComplete is a backward-compatible name for DefaultRootCommandComplete.
 var DefaultAppComplete = DefaultRootCommandComplete
 
-func printCommandSuggestions(commands []*Command, writer io.Writer) {
+func commandCandidates(commands []*Command) []Candidate {
+	var candidates []Candidate
 	for _, command := range commands {
 		if command.Hidden {
 			continue
 		}
-		if len(command.Usage) > 0 {
-			_, _ = fmt.Fprintf(writer, "%s:%s\n", command.Name, command.Usage)
-		} else {
-			_, _ = fmt.Fprintf(writer, "%s\n", command.Name)
-		}
+		candidates = append(candidates, Candidate{
+			Value:       command.Name,
+			Description: command.Usage,
+			Kind:        CandidateCommand,
+		})
 	}
+	return candidates
 }
 
 func cliArgContains(flagName string, args []string) bool {
@@ -212,7 +214,8 @@ func cliArgContains(flagName string, args []string) bool {
 	return false
 }
 
-func printFlagSuggestions(lastArg string, flags []Flag, writer io.Writer) {
+func flagCandidates(lastArg string, flags []Flag) []Candidate {
+	var candidates []Candidate
 	// Trim to handle both "-short" and "--long" flags.
 	cur := strings.TrimLeft(lastArg, "-")
 	for _, flag := range flags {
@@ -236,50 +239,174 @@ func printFlagSuggestions(lastArg string, flags []Flag, writer io.Writer) {
 		if strings.HasPrefix(lastArg, "--") && count == 1 {
 			continue
 		}
-		// match if last argument matches this flag and it is not repeated
-		if strings.HasPrefix(name, cur) && cur != name /* && !cliArgContains(name, os.Args)*/ {
-			flagCompletion := fmt.Sprintf("%s%s", strings.Repeat("-", count), name)
-			if usage != "" {
-				flagCompletion = fmt.Sprintf("%s:%s", flagCompletion, usage)
+		// match if last argument matches this flag
+		if strings.HasPrefix(name, cur) && cur != name {
+			candidates = append(candidates, Candidate{
+				Value:       strings.Repeat("-", count) + name,
+				Description: usage,
+				Kind:        CandidateFlag,
+			})
+		}
+	}
+	return candidates
+}
+
+// completionFlags returns the flags which may still be given to cmd: its
+// own flags and the persistent flags of its ancestors, less the OnlyOnce
+// flags already given and the flags mutually exclusive with one already
+// given
+func completionFlags(cmd *Command) []Flag {
+	given := map[Flag]struct{}{}
+	var groups []MutuallyExclusiveFlags
+	for _, c := range cmd.Lineage() {
+		for fl := range c.setFlags {
+			given[fl] = struct{}{}
+		}
+		groups = append(groups, c.MutuallyExclusiveFlags...)
+	}
+
+	excluded := map[Flag]struct{}{}
+	for _, grp := range groups {
+		for i, flags := range grp.Flags {
+			if !slices.ContainsFunc(flags, func(fl Flag) bool {
+				_, ok := given[fl]
+				return ok
+			}) {
+				continue
+			}
+			for j, others := range grp.Flags {
+				if j == i {
+					continue
+				}
+				for _, fl := range others {
+					excluded[fl] = struct{}{}
+				}
+			}
+		}
+	}
+
+	var flags []Flag
+	for _, fl := range cmd.applicableFlags() {
+		if _, ok := excluded[fl]; ok {
+			continue
+		}
+		if of, ok := fl.(OnlyOnceFlag); ok && of.IsOnlyOnce() {
+			if _, ok := given[fl]; ok {
+				continue
 			}
-			fmt.Fprintln(writer, flagCompletion)
 		}
+		flags = append(flags, fl)
 	}
+	return flags
+}
+
+func valueCandidates(values []string) []Candidate {
+	var candidates []Candidate
+	for _, value := range values {
+		candidates = append(candidates, Candidate{Value: value, Kind: CandidateValue})
+	}
+	return candidates
+}
+
+// flagValueCandidates returns the candidate values of the value taking flag
+// named by lastArg, followed by its completion directive. It returns false
+// if there is nothing to suggest.
+func flagValueCandidates(ctx context.Context, cmd *Command, lastArg string) ([]Candidate, bool) {
+	name := strings.TrimLeft(lastArg, "-")
+	for _, flag := range cmd.applicableFlags() {
+		if !slices.Contains(flag.Names(), name) {
+			continue
+		}
+		if df, ok := flag.(DocGenerationFlag); !ok || !df.TakesValue() {
+			return nil, false
+		}
+
+		var values []string
+		if vc, ok := flag.(ValueCompleter); ok {
+			values = vc.CompleteValue(ctx, cmd)
+		}
+		var directive CompletionDirective
+		if dc, ok := flag.(DirectiveCompleter); ok {
+			directive = dc.GetCompletionDirective()
+		}
+		if len(values) == 0 && directive.IsZero() {
+			return nil, false
+		}
+
+		candidates := valueCandidates(values)
+		if !directive.IsZero() {
+			candidates = append(candidates, Candidate{Kind: CandidateDirective, Directive: directive})
+		}
+		return candidates, true
+	}
+	return nil, false
+}
+
+// argumentCandidates returns the candidate values of the positional
+// argument following the given args, followed by its completion directive
+func argumentCandidates(ctx context.Context, cmd *Command, args []string) []Candidate {
+	pos := 0
+	for _, arg := range args {
+		if arg != "--" {
+			pos++
+		}
+	}
+
+	for _, arg := range cmd.Arguments {
+		n := 1
+		if ba, ok := arg.(boundedArgument); ok {
+			n = ba.maxValues()
+		}
+		if n >= 0 && pos >= n {
+			pos -= n
+			continue
+		}
+
+		var candidates []Candidate
+		if vc, ok := arg.(ValueCompleter); ok {
+			candidates = valueCandidates(vc.CompleteValue(ctx, cmd))
+		}
+		if dc, ok := arg.(DirectiveCompleter); ok {
+			if directive := dc.GetCompletionDirective(); !directive.IsZero() {
+				candidates = append(candidates, Candidate{Kind: CandidateDirective, Directive: directive})
+			}
+		}
+		return candidates
+	}
+	return nil
 }
 
 func DefaultCompleteWithFlags(ctx context.Context, cmd *Command) {
-	args := os.Args
-	if cmd != nil && cmd.parent != nil {
-		args = cmd.Args().Slice()
-		tracef("running default complete with flags[%v] on command %[2]q", args, cmd.Name)
-	} else {
-		tracef("running default complete with os.Args flags[%v]", args)
-	}
-	argsLen := len(args)
-	lastArg := ""
-	// parent command will have --generate-shell-completion so we need
-	// to account for that
-	if argsLen > 1 {
-		lastArg = args[argsLen-2]
-	} else if argsLen > 0 {
-		lastArg = args[argsLen-1]
+	PrintCandidates(cmd, completeWithFlags(ctx, cmd)...)
+}
+
+func completeWithFlags(ctx context.Context, cmd *Command) []Candidate {
+	var args []string
+	if cmd.Args() != nil {
+		// tests may leave --generate-shell-completion in the arguments
+		args = trimCompletionFlag(cmd.Args().Slice())
 	}
+	tracef("running default complete with flags[%v] on command %[2]q", args, cmd.Name)
 
-	if lastArg == completionFlag {
-		lastArg = ""
+	lastArg := ""
+	if len(args) > 0 {
+		lastArg = args[len(args)-1]
 	}
 
 	if strings.HasPrefix(lastArg, "-") {
-		tracef("printing flag suggestion for flag[%v] on command %[1]q", lastArg, cmd.Name)
-		printFlagSuggestions(lastArg, cmd.Flags, cmd.Root().Writer)
-		return
+		if candidates, ok := flagValueCandidates(ctx, cmd, lastArg); ok {
+			tracef("suggesting values for flag[%v] on command %[1]q", lastArg, cmd.Name)
+			return candidates
+		}
+		tracef("suggesting flags for flag[%v] on command %[1]q", lastArg, cmd.Name)
+		return append(flagCandidates(lastArg, completionFlags(cmd)), Candidate{
+			Kind:      CandidateDirective,
+			Directive: CompletionDirective{NoFileFallback: true},
+		})
 	}
 
-	if cmd != nil {
-		tracef("printing command suggestions on command %[1]q", cmd.Name)
-		printCommandSuggestions(cmd.Commands, cmd.Root().Writer)
-		return
-	}
+	tracef("suggesting commands and arguments on command %[1]q", cmd.Name)
+	return append(commandCandidates(cmd.Commands), argumentCandidates(ctx, cmd, args)...)
 }
 
 // ShowCommandHelpAndExit exits with code after showing help via ShowCommandHelp.
@@ -357,6 +484,25 @@ func DefaultPrintVersion(cmd *Command) {
 	_, _ = fmt.Fprintf(cmd.Root().Writer, "%v version %v\n", cmd.Name, cmd.Version)
 }
 
+// ShowSettings prints the final value of every flag of the command along
+// with where the value came from, e.g. for attaching to a bug report.
+// Values read from sources holding secrets, such as files read with
+// FileOptions.Sensitive and commands run by an ExecSource, are redacted.
+func ShowSettings(cmd *Command) {
+	w := tabwriter.NewWriter(cmd.Root().Writer, 1, 8, 2, ' ', 0)
+	for _, s := range cmd.Settings() {
+		var value any = s.Value
+		if ss, ok := s.Source.(sensitiveSource); ok && ss.sensitive() {
+			value = redactedValue
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s=%v\n", s.ValueProvenance, s.Name, value)
+	}
+	_ = w.Flush()
+}
+
+// redactedValue is shown by ShowSettings instead of secret values
+const redactedValue = "[redacted]"
+
 func handleTemplateError(err error) {
 	if err != nil {
 		tracef("error encountered during template parse: %[1]v", err)
@@ -444,6 +590,10 @@ func DefaultPrintHelpCustom(out io.Writer, templ string, data any, customFuncs m
 		handleTemplateError(err)
 	}
 
+	if _, err := t.New("visibleArgumentTemplate").Parse(visibleArgumentTemplate); err != nil {
+		handleTemplateError(err)
+	}
+
 	if _, err := t.New("visibleGlobalFlagCategoryTemplate").Parse(strings.ReplaceAll(visibleFlagCategoryTemplate, "OPTIONS", "GLOBAL OPTIONS")); err != nil {
 		handleTemplateError(err)
 	}
@@ -481,9 +631,8 @@ func checkShellCompleteFlag(c *Command, arguments []string) (bool, []string) {
 	}
 
 	pos := len(arguments) - 1
-	lastArg := arguments[pos]
-
-	if lastArg != completionFlag {
+	version, ok := parseCompletionFlag(arguments[pos])
+	if !ok {
 		return false, arguments
 	}
 
@@ -496,6 +645,7 @@ func checkShellCompleteFlag(c *Command, arguments []string) (bool, []string) {
 		return false, arguments[:pos]
 	}
 
+	c.completionVersion = version
 	return true, arguments[:pos]
 }
 
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -70,6 +71,69 @@ func TestArgumentRequiredUsageInCommandHelp(t *testing.T) {
 	}
 }
 
+func TestArgumentsHelpSection(t *testing.T) {
+	arguments := func() []Argument {
+		return []Argument{
+			&StringArg{Name: "dest", Required: true, Description: "directory to copy to"},
+			&EnumArg{Name: "mode", Value: "fast", Config: EnumConfig{Choices: []string{"fast", "safe"}}, Description: "how to copy"},
+			&IntArg{Name: "count", Sources: EnvVars("COUNT"), HideDefault: true},
+			&StringArgs{Name: "files", Max: -1, Description: "files to copy", DefaultText: "all files"},
+		}
+	}
+	expectedArgs := `ARGUMENTS:
+   dest string      directory to copy to
+   mode fast|safe   how to copy (default: "fast")
+   count int        ` + withEnvHint([]string{"COUNT"}, "") + `
+   files ...string  files to copy (default: all files)
+`
+
+	tests := []struct {
+		name          string
+		args          []string
+		expectedUsage string
+	}{
+		{
+			name:          "root command",
+			args:          []string{"cp", "--help"},
+			expectedUsage: "cp [global options] [command [command options]] dest [mode] [count] [files ...]",
+		},
+		{
+			name:          "subcommand",
+			args:          []string{"cp", "sub", "--help"},
+			expectedUsage: "cp sub [command [command options]] dest [mode] [count] [files ...]",
+		},
+		{
+			name:          "command",
+			args:          []string{"cp", "sub", "leaf", "--help"},
+			expectedUsage: "cp sub leaf [options] dest [mode] [count] [files ...]",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			cmd := &Command{
+				Name:      "cp",
+				Writer:    output,
+				Arguments: arguments(),
+				Commands: []*Command{
+					{
+						Name:      "sub",
+						Arguments: arguments(),
+						Commands: []*Command{
+							{Name: "leaf", Arguments: arguments()},
+						},
+					},
+				},
+			}
+
+			require.NoError(t, cmd.Run(buildTestContext(t), test.args))
+			require.Contains(t, output.String(), "USAGE:\n   "+test.expectedUsage+"\n")
+			require.Contains(t, output.String(), expectedArgs)
+		})
+	}
+}
+
 func Test_ShowRootCommandHelp_HideVersion(t *testing.T) {
 	output := new(bytes.Buffer)
 	cmd := &Command{Writer: output}
@@ -1417,7 +1481,7 @@ func TestDefaultCompleteWithFlags(t *testing.T) {
 			},
 			argv:     []string{"cmd", "--e", completionFlag},
 			env:      map[string]string{"SHELL": "bash"},
-			expected: "--excitement\n",
+			expected: "--excitement\n--everybody-jump-on\n",
 		},
 		{
 			name: "typical-flag-suggestion-hidden-bool",
@@ -1439,7 +1503,7 @@ func TestDefaultCompleteWithFlags(t *testing.T) {
 			},
 			argv:     []string{"cmd", "--e", completionFlag},
 			env:      map[string]string{"SHELL": "bash"},
-			expected: "",
+			expected: "--everybody-jump-on\n",
 		},
 		{
 			name: "typical-flag-suggestion-hidden-non-bool",
@@ -1461,7 +1525,7 @@ func TestDefaultCompleteWithFlags(t *testing.T) {
 			},
 			argv:     []string{"cmd", "--e", completionFlag},
 			env:      map[string]string{"SHELL": "bash"},
-			expected: "--excitement\n",
+			expected: "--excitement\n--everybody-jump-on\n",
 		},
 		{
 			name: "typical-flag-suggestion-hidden-bool-with-inverse",
@@ -1483,7 +1547,7 @@ func TestDefaultCompleteWithFlags(t *testing.T) {
 			},
 			argv:     []string{"cmd", "--e", completionFlag},
 			env:      map[string]string{"SHELL": "bash"},
-			expected: "--excitement\n",
+			expected: "--excitement\n--everybody-jump-on\n",
 		},
 		{
 			name: "flag-suggestion-double-dash-shows-all-flags",
@@ -1505,7 +1569,7 @@ func TestDefaultCompleteWithFlags(t *testing.T) {
 			},
 			argv:     []string{"cmd", "--e", "--", completionFlag},
 			env:      map[string]string{"SHELL": "bash"},
-			expected: "--excitement\n--hat-shape\n",
+			expected: "--excitement\n--hat-shape\n--happiness\n--everybody-jump-on\n",
 		},
 		{
 			name: "typical-command-suggestion",
@@ -1571,7 +1635,7 @@ func TestDefaultCompleteWithFlags(t *testing.T) {
 			},
 			argv:     []string{"cmd", "putz", "-e", completionFlag},
 			env:      map[string]string{"SHELL": "zsh"},
-			expected: "--excitement:an exciting flag\n",
+			expected: "--excitement:an exciting flag\n--everybody-jump-on\n",
 		},
 		{
 			name: "zsh-autocomplete-with-empty-flag-descriptions",
@@ -1591,7 +1655,7 @@ func TestDefaultCompleteWithFlags(t *testing.T) {
 			},
 			argv:     []string{"cmd", "putz", "-e", completionFlag},
 			env:      map[string]string{"SHELL": "zsh"},
-			expected: "--excitement\n",
+			expected: "--excitement\n--everybody-jump-on\n",
 		},
 	} {
 		t.Run(tc.name, func(ct *testing.T) {
@@ -1616,6 +1680,128 @@ func TestDefaultCompleteWithFlags(t *testing.T) {
 	}
 }
 
+func TestDefaultCompleteWithFlagsValues(t *testing.T) {
+	regions := func(context.Context, *Command) []string {
+		return []string{"us-east", "eu-west"}
+	}
+	kinds := func(context.Context, *Command) []string {
+		return []string{"pod", "svc"}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "flag value",
+			args:     []string{"foo", "get", "--region", completionFlag},
+			expected: "us-east\neu-west\n",
+		},
+		{
+			name:     "flag value after positional args",
+			args:     []string{"foo", "get", "pod", "--region", completionFlag},
+			expected: "us-east\neu-west\n",
+		},
+		{
+			name:     "file flag",
+			args:     []string{"foo", "get", "--out", completionFlag},
+			expected: "",
+		},
+		{
+			name:     "file flag with directives",
+			args:     []string{"foo", "get", "--out", completionFlag + "=2"},
+			expected: ":files\n",
+		},
+		{
+			name:     "directory flag with directives",
+			args:     []string{"foo", "get", "--dir", completionFlag + "=2"},
+			expected: ":dirs nospace\n",
+		},
+		{
+			name:     "escaped values with directives",
+			args:     []string{"foo", "get", "--endpoint", completionFlag + "=2"},
+			expected: "http\\://localhost\n:files=json,yaml keeporder\n",
+		},
+		{
+			name:     "flag without values",
+			args:     []string{"foo", "get", "--name", completionFlag},
+			expected: "",
+		},
+		{
+			name:     "flag name",
+			args:     []string{"foo", "get", "--reg", completionFlag},
+			expected: "--region\n",
+		},
+		{
+			name:     "flag name with directives",
+			args:     []string{"foo", "get", "--reg", completionFlag + "=2"},
+			expected: "--region\n:nofiles\n",
+		},
+		{
+			name:     "first argument",
+			args:     []string{"foo", "get", completionFlag},
+			expected: "pod\nsvc\n",
+		},
+		{
+			name:     "second argument",
+			args:     []string{"foo", "get", "pod", completionFlag},
+			expected: "json\nyaml\n",
+		},
+		{
+			name:     "slice argument",
+			args:     []string{"foo", "get", "pod", "json", "--region", "us-east", "yaml", completionFlag},
+			expected: "json\nyaml\n",
+		},
+		{
+			name:     "file argument with directives",
+			args:     []string{"foo", "put", completionFlag + "=2"},
+			expected: ":files\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			cmd := &Command{
+				EnableShellCompletion: true,
+				Writer:                out,
+				Commands: []*Command{
+					{
+						Name:            "get",
+						HideHelpCommand: true,
+						Flags: []Flag{
+							&StringFlag{Name: "region", ShellComplete: regions},
+							&StringFlag{Name: "out", TakesFile: true},
+							&StringFlag{Name: "name"},
+							&StringFlag{Name: "dir", CompletionDirective: CompletionDirective{Dirs: true, NoSpace: true}},
+							&StringFlag{
+								Name: "endpoint",
+								ShellComplete: func(context.Context, *Command) []string {
+									return []string{"http://localhost"}
+								},
+								CompletionDirective: CompletionDirective{FileExtensions: []string{"json", "yaml"}, KeepOrder: true},
+							},
+						},
+						Arguments: []Argument{
+							&StringArg{Name: "kind", ShellComplete: kinds},
+							&EnumArgs{Name: "format", Max: -1, Config: EnumConfig{Choices: []string{"json", "yaml"}}},
+						},
+					},
+					{
+						Name:            "put",
+						HideHelpCommand: true,
+						Arguments: []Argument{
+							&StringArg{Name: "file", TakesFile: true},
+						},
+					},
+				},
+			}
+
+			require.NoError(t, cmd.Run(buildTestContext(t), tc.args))
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
 func TestMutuallyExclusiveFlags(t *testing.T) {
 	writer := &bytes.Buffer{}
 	cmd := &Command{
@@ -2024,6 +2210,7 @@ func Test_checkShellCompleteFlag(t *testing.T) {
 		arguments           []string
 		wantShellCompletion bool
 		wantArgs            []string
+		wantVersion         int
 	}{
 		{
 			name:                "disable-shell-completion",
@@ -2067,6 +2254,26 @@ func Test_checkShellCompleteFlag(t *testing.T) {
 			},
 			wantShellCompletion: true,
 			wantArgs:            []string{"foo"},
+			wantVersion:         1,
+		},
+		{
+			name:      "shell completion with version",
+			arguments: []string{"foo", completionFlag + "=2"},
+			cmd: &Command{
+				EnableShellCompletion: true,
+			},
+			wantShellCompletion: true,
+			wantArgs:            []string{"foo"},
+			wantVersion:         2,
+		},
+		{
+			name:      "shell completion with invalid version",
+			arguments: []string{"foo", completionFlag + "=two"},
+			cmd: &Command{
+				EnableShellCompletion: true,
+			},
+			wantShellCompletion: false,
+			wantArgs:            []string{"foo", completionFlag + "=two"},
 		},
 		{
 			name:      "double dash is the token being completed",
@@ -2076,6 +2283,7 @@ func Test_checkShellCompleteFlag(t *testing.T) {
 			},
 			wantShellCompletion: true,
 			wantArgs:            []string{"foo", "--"},
+			wantVersion:         1,
 		},
 		{
 			name:      "no arguments at all",
@@ -2095,6 +2303,7 @@ func Test_checkShellCompleteFlag(t *testing.T) {
 			shellCompletion, args := checkShellCompleteFlag(tt.cmd, tt.arguments)
 			assert.Equal(t, tt.wantShellCompletion, shellCompletion)
 			assert.Equal(t, tt.wantArgs, args)
+			assert.Equal(t, tt.wantVersion, tt.cmd.completionVersion)
 		})
 	}
 }
@@ -2234,6 +2443,7 @@ func TestPrintHelpCustomTemplateError(t *testing.T) {
 		&visibleFlagCategoryTemplate,
 		&visibleFlagTemplate,
 		&visiblePersistentFlagTemplate,
+		&visibleArgumentTemplate,
 		&visibleFlagCategoryTemplate,
 		&authorsTemplate,
 		&visibleCommandCategoryTemplate,
@@ -2285,3 +2495,72 @@ func TestCustomUsageCommandHelp(t *testing.T) {
 	_ = cmd.Run(buildTestContext(t), []string{"app", "help"})
 	assert.Contains(t, out.String(), UsageCommandHelp)
 }
+
+func TestShowSettings(t *testing.T) {
+	t.Setenv("TEST_SHOW_SETTINGS_REGION", "eu-west")
+
+	out := &bytes.Buffer{}
+	cmd := &Command{
+		Name:   "app",
+		Writer: out,
+		Flags: []Flag{
+			&BoolFlag{Name: "verbose"},
+		},
+		Commands: []*Command{
+			{
+				Name: "deploy",
+				Flags: []Flag{
+					&StringFlag{Name: "region", Sources: EnvVars("TEST_SHOW_SETTINGS_REGION")},
+					&IntFlag{Name: "replicas", Value: 1},
+				},
+				Action: func(_ context.Context, cmd *Command) error {
+					ShowSettings(cmd)
+					return nil
+				},
+			},
+		},
+	}
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app", "--verbose", "deploy"}))
+	assert.Equal(t, `command line                                      verbose=true
+environment variable "TEST_SHOW_SETTINGS_REGION"  region=eu-west
+default                                           replicas=1
+default                                           help=false
+`, out.String())
+}
+
+func TestShowSettingsRedactsSecrets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test runs a command with cat")
+	}
+
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "secret")
+	tokenPath := filepath.Join(dir, "token")
+	userPath := filepath.Join(dir, "user")
+	require.NoError(t, os.WriteFile(secretPath, []byte("s3cret-file"), 0o600))
+	require.NoError(t, os.WriteFile(tokenPath, []byte("s3cret-exec"), 0o600))
+	require.NoError(t, os.WriteFile(userPath, []byte("admin"), 0o600))
+
+	out := &bytes.Buffer{}
+	cmd := &Command{
+		Name:   "app",
+		Writer: out,
+		Flags: []Flag{
+			&StringFlag{Name: "password", Sources: NewValueSourceChain(FileWithOptions(secretPath, FileOptions{Sensitive: true}))},
+			&StringFlag{Name: "token", Sources: NewValueSourceChain(Exec("cat", tokenPath))},
+			&StringFlag{Name: "user", Sources: Files(userPath)},
+		},
+		Action: func(_ context.Context, cmd *Command) error {
+			ShowSettings(cmd)
+			return nil
+		},
+	}
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+	assert.Contains(t, out.String(), "password=[redacted]\n")
+	assert.Contains(t, out.String(), "token=[redacted]\n")
+	assert.Contains(t, out.String(), "user=admin\n")
+	assert.NotContains(t, out.String(), "s3cret")
+	assert.Equal(t, "s3cret-file", cmd.String("password"))
+}
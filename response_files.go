@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// expandResponseFiles replaces each @file argument of args following the
+// program name by the arguments read from file, which may themselves be
+// @file arguments. Arguments starting with @@ stand for the argument
+// without its first @, and the arguments following a "--" are left as they
+// are.
+func expandResponseFiles(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+
+	e := &responseFileExpander{args: []string{args[0]}}
+	for _, arg := range args[1:] {
+		if err := e.expandArg(arg, "", 0); err != nil {
+			return nil, err
+		}
+	}
+
+	tracef("expanded response files in %[1]q to %[2]q", args, e.args)
+
+	return e.args, nil
+}
+
+type responseFileExpander struct {
+	args []string
+	// files are the absolute paths of the files being expanded, to detect
+	// files which include themselves
+	files []string
+	// done is set once a "--" has been seen
+	done bool
+}
+
+// expandArg appends arg to the expanded arguments, or the arguments read
+// from the file it names. from and line give the response file arg was
+// read from, if any.
+func (e *responseFileExpander) expandArg(arg string, from string, line int) error {
+	switch {
+	case e.done || len(arg) < 2 || arg[0] != '@':
+		e.done = e.done || arg == "--"
+		e.args = append(e.args, arg)
+	case arg[1] == '@':
+		e.args = append(e.args, arg[1:])
+	default:
+		return e.expandFile(arg[1:], from, line)
+	}
+	return nil
+}
+
+func (e *responseFileExpander) expandFile(path string, from string, line int) error {
+	refError := func(err error) error {
+		if from == "" {
+			return &ResponseFileError{File: path, Err: err}
+		}
+		return &ResponseFileError{File: from, Line: line, Err: fmt.Errorf("could not read response file %q: %w", path, err)}
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return refError(err)
+	}
+	if slices.Contains(e.files, abs) {
+		return &ResponseFileError{
+			File: from,
+			Line: line,
+			Err:  fmt.Errorf("response file %q includes itself", path),
+		}
+	}
+
+	tracef("expanding response file %[1]q", path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return refError(err)
+	}
+	words, err := splitShellWords(string(data))
+	if err != nil {
+		var swErr *ShellWordsError
+		if errors.As(err, &swErr) {
+			line = swErr.Line
+		}
+		return &ResponseFileError{File: path, Line: line, Err: err}
+	}
+
+	e.files = append(e.files, abs)
+	for _, w := range words {
+		if err := e.expandArg(w.text, path, w.line); err != nil {
+			return err
+		}
+	}
+	e.files = e.files[:len(e.files)-1]
+
+	return nil
+}
+
+// ResponseFileError is returned when the arguments of a response file,
+// given as an @file argument to a command expanding them, can't be read
+type ResponseFileError struct {
+	// File is the path of the response file
+	File string
+	// Line is the line of the error in the file, or 0 if the error is
+	// about the whole file
+	Line int
+	// Err is the reason of the failure
+	Err error
+}
+
+func (e *ResponseFileError) Error() string {
+	var swErr *ShellWordsError
+	switch {
+	case errors.As(e.Err, &swErr):
+		return fmt.Sprintf("response file %q: line %d, column %d: %s", e.File, swErr.Line, swErr.Column, swErr.Msg)
+	case e.Line > 0:
+		return fmt.Sprintf("response file %q: line %d: %v", e.File, e.Line, e.Err)
+	default:
+		return fmt.Sprintf("response file %q: %v", e.File, e.Err)
+	}
+}
+
+func (e *ResponseFileError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ShellWordsError is returned by SplitShellWords when its input isn't
+// well formed, for instance when a quote isn't closed
+type ShellWordsError struct {
+	// Line is the line of the error in the input, starting at 1
+	Line int
+	// Column is the column of the error in its line, in runes starting at 1
+	Column int
+	// Msg describes the error
+	Msg string
+}
+
+func (e *ShellWordsError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// SplitShellWords splits input into words the way a POSIX shell splits a
+// command line, without performing any expansion.
+//
+// Words are separated by blanks and newlines. Characters in single quotes
+// are taken literally. In double quotes, a backslash only escapes $, `, ",
+// \ and newlines. Elsewhere, a backslash escapes any character. A
+// backslash followed by a newline continues the line, and a # starting a
+// word begins a comment running to the end of the line. Quoted empty
+// strings are kept as empty words.
+func SplitShellWords(input string) ([]string, error) {
+	words, err := splitShellWords(input)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = w.text
+	}
+	return texts, nil
+}
+
+// shellWord is a word split by splitShellWords along with the line it
+// starts on
+type shellWord struct {
+	text string
+	line int
+}
+
+func splitShellWords(input string) ([]shellWord, error) {
+	s := &shellWordsScanner{input: []rune(input), line: 1}
+	return s.split()
+}
+
+type shellWordsScanner struct {
+	input  []rune
+	pos    int
+	line   int
+	column int
+}
+
+func (s *shellWordsScanner) errorf(line, column int, format string, a ...any) error {
+	return &ShellWordsError{Line: line, Column: column, Msg: fmt.Sprintf(format, a...)}
+}
+
+func (s *shellWordsScanner) next() (rune, bool) {
+	if s.pos >= len(s.input) {
+		return 0, false
+	}
+	r := s.input[s.pos]
+	s.pos++
+	if r == '\n' {
+		s.line++
+		s.column = 0
+	} else {
+		s.column++
+	}
+	return r, true
+}
+
+func (s *shellWordsScanner) skipLine() {
+	for {
+		if r, ok := s.next(); !ok || r == '\n' {
+			return
+		}
+	}
+}
+
+func (s *shellWordsScanner) split() ([]shellWord, error) {
+	var (
+		words  []shellWord
+		word   strings.Builder
+		inWord bool
+		line   int
+	)
+
+	for {
+		if !inWord {
+			line = s.line
+		}
+		r, ok := s.next()
+		if !ok {
+			break
+		}
+
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			if inWord {
+				words = append(words, shellWord{text: word.String(), line: line})
+				word.Reset()
+				inWord = false
+			}
+		case '#':
+			if !inWord {
+				s.skipLine()
+				continue
+			}
+			word.WriteRune(r)
+		case '\\':
+			line, column := s.line, s.column
+			e, ok := s.next()
+			if !ok {
+				return nil, s.errorf(line, column, "backslash at end of input")
+			}
+			if e != '\n' {
+				word.WriteRune(e)
+				inWord = true
+			}
+		case '\'':
+			inWord = true
+			if err := s.singleQuoted(&word); err != nil {
+				return nil, err
+			}
+		case '"':
+			inWord = true
+			if err := s.doubleQuoted(&word); err != nil {
+				return nil, err
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, shellWord{text: word.String(), line: line})
+	}
+	return words, nil
+}
+
+// singleQuoted writes the characters up to the closing single quote to b
+func (s *shellWordsScanner) singleQuoted(b *strings.Builder) error {
+	line, column := s.line, s.column
+	for {
+		r, ok := s.next()
+		if !ok {
+			return s.errorf(line, column, "unterminated single-quoted string")
+		}
+		if r == '\'' {
+			return nil
+		}
+		b.WriteRune(r)
+	}
+}
+
+// doubleQuoted writes the characters up to the closing double quote to b,
+// handling the escapes allowed in double quotes
+func (s *shellWordsScanner) doubleQuoted(b *strings.Builder) error {
+	line, column := s.line, s.column
+	for {
+		r, ok := s.next()
+		if !ok {
+			return s.errorf(line, column, "unterminated double-quoted string")
+		}
+
+		switch r {
+		case '"':
+			return nil
+		case '\\':
+			e, ok := s.next()
+			if !ok {
+				return s.errorf(line, column, "unterminated double-quoted string")
+			}
+			switch e {
+			case '$', '`', '"', '\\':
+				b.WriteRune(e)
+			case '\n':
+			default:
+				b.WriteRune('\\')
+				b.WriteRune(e)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
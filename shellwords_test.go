@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "empty",
+			input:    "",
+			expected: []string{},
+		},
+		{
+			name:     "blanks",
+			input:    " \t\r\n ",
+			expected: []string{},
+		},
+		{
+			name:     "words",
+			input:    "  foo bar\tbaz\nqux  ",
+			expected: []string{"foo", "bar", "baz", "qux"},
+		},
+		{
+			name:     "single quotes",
+			input:    `'foo bar' 'it\s' '"$x"'`,
+			expected: []string{"foo bar", `it\s`, `"$x"`},
+		},
+		{
+			name:     "double quotes",
+			input:    `"foo bar" "a \"b\" \\ \$x \n" "multi` + "\n" + `line"`,
+			expected: []string{"foo bar", `a "b" \ $x \n`, "multi\nline"},
+		},
+		{
+			name:     "adjacent quotes make one word",
+			input:    `foo'bar'"baz" --flag="a b"`,
+			expected: []string{"foobarbaz", "--flag=a b"},
+		},
+		{
+			name:     "empty quoted words",
+			input:    `'' "" foo`,
+			expected: []string{"", "", "foo"},
+		},
+		{
+			name:     "backslash escapes",
+			input:    `foo\ bar \'quoted\' \#not-comment`,
+			expected: []string{"foo bar", "'quoted'", "#not-comment"},
+		},
+		{
+			name:     "line continuations",
+			input:    "foo \\\nbar ba\\\nz \"x\\\ny\"",
+			expected: []string{"foo", "bar", "baz", "xy"},
+		},
+		{
+			name:     "comments",
+			input:    "# leading comment\nfoo # trailing comment\nbar#baz '#quoted'",
+			expected: []string{"foo", "bar#baz", "#quoted"},
+		},
+		{
+			name:     "unicode",
+			input:    `héllo 'wörld' "日本"`,
+			expected: []string{"héllo", "wörld", "日本"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			words, err := SplitShellWords(test.input)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, words)
+		})
+	}
+}
+
+func TestSplitShellWordsErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{
+			name:        "unterminated single quote",
+			input:       "foo\n  bar 'baz",
+			expectedErr: "line 2, column 7: unterminated single-quoted string",
+		},
+		{
+			name:        "unterminated double quote",
+			input:       `foo "bar \"`,
+			expectedErr: "line 1, column 5: unterminated double-quoted string",
+		},
+		{
+			name:        "unterminated multiline double quote",
+			input:       "\"foo\nbar",
+			expectedErr: "line 1, column 1: unterminated double-quoted string",
+		},
+		{
+			name:        "trailing backslash",
+			input:       "foo\nbär \\",
+			expectedErr: "line 2, column 5: backslash at end of input",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			words, err := SplitShellWords(test.input)
+			assert.Nil(t, words)
+			require.EqualError(t, err, test.expectedErr)
+
+			var swErr *ShellWordsError
+			require.ErrorAs(t, err, &swErr)
+		})
+	}
+}
@@ -145,3 +145,18 @@ func suggestCommand(commands []*Command, provided string) (suggestion string) {
 
 	return suggestion
 }
+
+// suggestChoice takes a list of allowed values and a provided string to
+// suggest the closest allowed value
+func suggestChoice(choices []string, provided string) (suggestion string) {
+	distance := 0.0
+	for _, choice := range choices {
+		newDistance := jaroWinkler(choice, provided)
+		if newDistance > distance {
+			distance = newDistance
+			suggestion = choice
+		}
+	}
+
+	return suggestion
+}
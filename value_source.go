@@ -1,8 +1,14 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"runtime"
+	"slices"
 	"strings"
 )
 
@@ -17,6 +23,17 @@ type ValueSource interface {
 	Lookup() (string, bool)
 }
 
+// ErrorValueSource is a ValueSource whose lookup can fail for another
+// reason than the value not being there, such as a file which exists
+// but can't be read
+type ErrorValueSource interface {
+	ValueSource
+
+	// LookupE returns the value from the source and if it was found,
+	// or the error which prevented it from being read
+	LookupE() (string, bool, error)
+}
+
 // EnvValueSource is to specifically detect env sources when
 // printing help text
 type EnvValueSource interface {
@@ -37,6 +54,16 @@ type MapSource interface {
 	Lookup(string) (any, bool)
 }
 
+// ErrorMapSource is a MapSource whose lookup can fail for another reason
+// than the key not being there, such as a file which can't be parsed
+type ErrorMapSource interface {
+	MapSource
+
+	// LookupE returns the value from the source based on key and if it
+	// was found, or the error which prevented it from being read
+	LookupE(string) (any, bool, error)
+}
+
 // ValueSourceChain contains an ordered series of ValueSource that
 // allows for lookup where the first ValueSource to resolve is
 // returned
@@ -58,7 +85,7 @@ func (vsc *ValueSourceChain) EnvKeys() []string {
 	vals := []string{}
 
 	for _, src := range vsc.Chain {
-		if v, ok := src.(EnvValueSource); ok && v.IsFromEnv() {
+		if v, ok := src.(EnvValueSource); ok && v.IsFromEnv() && !slices.Contains(vals, v.Key()) {
 			vals = append(vals, v.Key())
 		}
 	}
@@ -91,6 +118,9 @@ func (vsc *ValueSourceChain) Lookup() (string, bool) {
 	return s, ok
 }
 
+// LookupWithSource returns the value of the first source of the chain
+// which resolves, along with that source. Sources failing to read their
+// value are skipped, see LookupWithSourceE.
 func (vsc *ValueSourceChain) LookupWithSource() (string, ValueSource, bool) {
 	for _, src := range vsc.Chain {
 		if value, found := src.Lookup(); found {
@@ -101,6 +131,52 @@ func (vsc *ValueSourceChain) LookupWithSource() (string, ValueSource, bool) {
 	return "", nil, false
 }
 
+// LookupWithSourceE is like LookupWithSource, but stops at the first
+// source failing to read its value and returns a *ValueSourceError
+// naming it
+func (vsc *ValueSourceChain) LookupWithSourceE() (string, ValueSource, bool, error) {
+	for _, src := range vsc.Chain {
+		es, ok := src.(ErrorValueSource)
+		if !ok {
+			if value, found := src.Lookup(); found {
+				return value, src, true, nil
+			}
+			continue
+		}
+
+		value, found, err := es.LookupE()
+		if err != nil {
+			return "", src, false, &ValueSourceError{Source: src, Err: err}
+		}
+		if found {
+			return value, src, true, nil
+		}
+	}
+
+	return "", nil, false, nil
+}
+
+// lookupAll returns the values of all the sources of the chain which
+// resolve, in the order of the chain, along with those sources
+func (vsc *ValueSourceChain) lookupAll() ([]string, []ValueSource, error) {
+	var (
+		vals    []string
+		sources []ValueSource
+	)
+	for i := range vsc.Chain {
+		rest := ValueSourceChain{Chain: vsc.Chain[i : i+1]}
+		val, src, found, err := rest.LookupWithSourceE()
+		if err != nil {
+			return nil, nil, err
+		}
+		if found {
+			vals = append(vals, val)
+			sources = append(sources, src)
+		}
+	}
+	return vals, sources, nil
+}
+
 // envVarValueSource encapsulates a ValueSource from an environment variable
 type envVarValueSource struct {
 	key string
@@ -141,32 +217,159 @@ func EnvVars(keys ...string) ValueSourceChain {
 	return vsc
 }
 
+// FileOptions configures how a file value source reads its value, see
+// FileWithOptions and FilesWithOptions
+type FileOptions struct {
+	// TrimNewline removes a single trailing newline from the value, as
+	// written by editors and found in Kubernetes or Docker secret mounts
+	TrimNewline bool
+	// TrimSpace removes all leading and trailing white space from the value
+	TrimSpace bool
+	// MaxSize is the largest size of the file in bytes, a larger file being
+	// an error. Zero means no limit.
+	MaxSize int64
+	// Sensitive refuses files which can be read by their group or by others,
+	// for flags whose value is a secret, except on Windows. ShowSettings
+	// redacts the values read from such files.
+	Sensitive bool
+	// ExpandPath resolves a leading ~ to the home directory of the user and
+	// $VAR or ${VAR} to the value of environment variables in the path
+	ExpandPath bool
+}
+
+// sensitiveSource is implemented by value sources which may hold secrets,
+// whose values are redacted by ShowSettings
+type sensitiveSource interface {
+	sensitive() bool
+}
+
 // fileValueSource encapsulates a ValueSource from a file
 type fileValueSource struct {
 	Path string
+	opts FileOptions
 }
 
 func (f *fileValueSource) Lookup() (string, bool) {
-	data, err := os.ReadFile(f.Path)
-	return string(data), err == nil
+	s, found, _ := f.LookupE()
+	return s, found
+}
+
+// LookupE returns the content of the file. A file which doesn't exist is
+// not found, while any other failure to read it is an error.
+func (f *fileValueSource) LookupE() (string, bool, error) {
+	path := f.Path
+	if f.opts.ExpandPath {
+		var err error
+		if path, err = expandPath(path); err != nil {
+			return "", false, err
+		}
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	defer file.Close()
+
+	if err := f.checkFile(file); err != nil {
+		return "", false, err
+	}
+
+	var r io.Reader = file
+	if f.opts.MaxSize > 0 {
+		r = io.LimitReader(file, f.opts.MaxSize+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", false, err
+	}
+	if f.opts.MaxSize > 0 && int64(len(data)) > f.opts.MaxSize {
+		return "", false, fmt.Errorf("file is larger than %d bytes", f.opts.MaxSize)
+	}
+
+	val := string(data)
+	if f.opts.TrimSpace {
+		val = strings.TrimSpace(val)
+	} else if f.opts.TrimNewline {
+		if v, ok := strings.CutSuffix(val, "\n"); ok {
+			val = strings.TrimSuffix(v, "\r")
+		}
+	}
+	return val, true, nil
+}
+
+// sensitive returns whether the file holds a secret
+func (f *fileValueSource) sensitive() bool {
+	return f.opts.Sensitive
+}
+
+// checkFile checks the size and permissions of the file against the
+// options of the source
+func (f *fileValueSource) checkFile(file *os.File) error {
+	if !f.opts.Sensitive && f.opts.MaxSize <= 0 {
+		return nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if f.opts.MaxSize > 0 && info.Mode().IsRegular() && info.Size() > f.opts.MaxSize {
+		return fmt.Errorf("file is larger than %d bytes", f.opts.MaxSize)
+	}
+	if f.opts.Sensitive && runtime.GOOS != "windows" && info.Mode().Perm()&0o044 != 0 {
+		return fmt.Errorf("file holding a sensitive value is readable by group or others (mode %04o)", info.Mode().Perm())
+	}
+	return nil
+}
+
+// expandPath resolves a leading ~ and environment variables in path
+func expandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(os.PathSeparator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = home + path[1:]
+	}
+	return os.ExpandEnv(path), nil
 }
 
 func (f *fileValueSource) String() string { return fmt.Sprintf("file %[1]q", f.Path) }
 func (f *fileValueSource) GoString() string {
-	return fmt.Sprintf("&fileValueSource{Path:%[1]q}", f.Path)
+	if f.opts == (FileOptions{}) {
+		return fmt.Sprintf("&fileValueSource{Path:%[1]q}", f.Path)
+	}
+	return fmt.Sprintf("&fileValueSource{Path:%[1]q, Options:%+[2]v}", f.Path, f.opts)
 }
 
+// File returns a ValueSource reading the raw content of the file at path
 func File(path string) ValueSource {
 	return &fileValueSource{Path: path}
 }
 
+// FileWithOptions returns a ValueSource reading the content of the file at
+// path as configured by opts
+func FileWithOptions(path string, opts FileOptions) ValueSource {
+	return &fileValueSource{Path: path, opts: opts}
+}
+
 // Files is a helper function to encapsulate a number of
 // fileValueSource together as a ValueSourceChain
 func Files(paths ...string) ValueSourceChain {
+	return FilesWithOptions(FileOptions{}, paths...)
+}
+
+// FilesWithOptions is like Files, with every file read as configured by
+// opts
+func FilesWithOptions(opts FileOptions, paths ...string) ValueSourceChain {
 	vsc := ValueSourceChain{Chain: []ValueSource{}}
 
 	for _, path := range paths {
-		vsc.Chain = append(vsc.Chain, File(path))
+		vsc.Chain = append(vsc.Chain, FileWithOptions(path, opts))
 	}
 
 	return vsc
@@ -255,3 +458,36 @@ func (mvs *mapValueSource) Lookup() (string, bool) {
 		return fmt.Sprintf("%+v", v), true
 	}
 }
+
+func (mvs *mapValueSource) LookupE() (string, bool, error) {
+	ems, ok := mvs.ms.(ErrorMapSource)
+	if !ok {
+		s, found := mvs.Lookup()
+		return s, found, nil
+	}
+	if v, found, err := ems.LookupE(mvs.key); err != nil || !found {
+		return "", false, err
+	} else {
+		return fmt.Sprintf("%+v", v), true, nil
+	}
+}
+
+// startRun tells the map source about the start of a run if it needs it
+func (mvs *mapValueSource) startRun(ctx context.Context, args []string) {
+	if s, ok := mvs.ms.(runSource); ok {
+		s.startRun(ctx, args)
+	}
+}
+
+// reload reloads the map source if it caches what it reads
+func (mvs *mapValueSource) reload() {
+	if r, ok := mvs.ms.(reloadableSource); ok {
+		r.reload()
+	}
+}
+
+// cloneSource copies the source along with its map source, which may keep
+// state for a run
+func (mvs *mapValueSource) cloneSource(sc *sourceCloner) any {
+	return &mapValueSource{key: mvs.key, ms: sc.clone(mvs.ms).(MapSource)}
+}
@@ -0,0 +1,371 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// DotEnv is a MapSource reading variables from a dotenv file, as written
+// by many deployment tools instead of exporting them. The file is read
+// once per run of the root command, on the first lookup.
+//
+// The file holds one NAME=value assignment per line, optionally prefixed
+// by export. Lines starting with # and text after a # preceded by a space
+// in unquoted values are comments. Values in single quotes are taken
+// literally, while values in double quotes may contain the escapes \n,
+// \r, \t, \", \\ and \$. Quoted values may span several lines. ${NAME} in
+// unquoted and double-quoted values is replaced by the value of a
+// variable assigned earlier in the file, or else of the environment.
+type DotEnv struct {
+	// Path is the path of the dotenv file
+	Path string
+
+	loaded bool
+	vars   map[string]string
+	err    error
+}
+
+// NewDotEnv returns a DotEnv reading the file at path
+func NewDotEnv(path string) *DotEnv {
+	return &DotEnv{Path: path}
+}
+
+// EnvVar returns a ValueSource looking up the variable key in the dotenv
+// file. It is shown in help text like an environment variable.
+func (d *DotEnv) EnvVar(key string) ValueSource {
+	return &dotEnvValueSource{key: key, d: d}
+}
+
+// EnvVars returns a chain looking up each of the keys in the environment
+// and then in the dotenv file, so that variables which are exported
+// override the ones from the file
+func (d *DotEnv) EnvVars(keys ...string) ValueSourceChain {
+	vsc := ValueSourceChain{Chain: []ValueSource{}}
+
+	for _, key := range keys {
+		vsc.Chain = append(vsc.Chain, EnvVar(key), d.EnvVar(key))
+	}
+
+	return vsc
+}
+
+func (d *DotEnv) String() string { return fmt.Sprintf("dotenv file %[1]q", d.Path) }
+func (d *DotEnv) GoString() string {
+	return fmt.Sprintf("&DotEnv{Path:%[1]q}", d.Path)
+}
+
+// Lookup returns the value of the variable name in the dotenv file.
+// Nothing is found when the file can't be read, see LookupE.
+func (d *DotEnv) Lookup(name string) (any, bool) {
+	val, found, _ := d.LookupE(name)
+	return val, found
+}
+
+// LookupE returns the value of the variable name in the dotenv file. A
+// file which doesn't exist holds no variables, while a file which can't
+// be read or parsed is an error.
+func (d *DotEnv) LookupE(name string) (any, bool, error) {
+	if !d.loaded {
+		d.load()
+	}
+	if d.err != nil {
+		return nil, false, d.err
+	}
+	val, ok := d.vars[name]
+	return val, ok, nil
+}
+
+func (d *DotEnv) load() {
+	tracef("loading dotenv file %[1]q", d.Path)
+
+	d.vars = map[string]string{}
+	d.loaded = true
+
+	data, err := os.ReadFile(d.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		d.err = err
+		return
+	}
+	if vars, err := parseDotEnv(string(data)); err != nil {
+		d.err = err
+	} else {
+		d.vars = vars
+	}
+}
+
+// reload forgets the file read so far, to read it again on the next lookup
+func (d *DotEnv) reload() {
+	d.loaded = false
+	d.vars = nil
+	d.err = nil
+}
+
+// startRun forgets the file read by a previous run
+func (d *DotEnv) startRun(context.Context, []string) {
+	d.reload()
+}
+
+// cloneSource returns a DotEnv reading the same file, which has read
+// nothing yet
+func (d *DotEnv) cloneSource(*sourceCloner) any {
+	return &DotEnv{Path: d.Path}
+}
+
+// dotEnvValueSource encapsulates a ValueSource from a variable of a
+// dotenv file
+type dotEnvValueSource struct {
+	key string
+	d   *DotEnv
+}
+
+func (e *dotEnvValueSource) Lookup() (string, bool) {
+	val, found, _ := e.LookupE()
+	return val, found
+}
+
+func (e *dotEnvValueSource) LookupE() (string, bool, error) {
+	val, found, err := e.d.LookupE(e.key)
+	if err != nil || !found {
+		return "", false, err
+	}
+	return val.(string), true, nil
+}
+
+func (e *dotEnvValueSource) IsFromEnv() bool {
+	return true
+}
+
+func (e *dotEnvValueSource) Key() string {
+	return e.key
+}
+
+// reload reloads the dotenv file on the next lookup
+func (e *dotEnvValueSource) reload() {
+	e.d.reload()
+}
+
+// startRun tells the dotenv file about the start of a run
+func (e *dotEnvValueSource) startRun(ctx context.Context, args []string) {
+	e.d.startRun(ctx, args)
+}
+
+func (e *dotEnvValueSource) cloneSource(sc *sourceCloner) any {
+	return &dotEnvValueSource{key: e.key, d: sc.clone(e.d).(*DotEnv)}
+}
+
+func (e *dotEnvValueSource) String() string {
+	return fmt.Sprintf("variable %[1]q from %[2]s", e.key, e.d.String())
+}
+
+func (e *dotEnvValueSource) GoString() string {
+	return fmt.Sprintf("&dotEnvValueSource{Key:%[1]q, src:%[2]s}", e.key, e.d.GoString())
+}
+
+// parseDotEnv returns the variables assigned in the dotenv file data
+func parseDotEnv(data string) (map[string]string, error) {
+	p := &dotEnvParser{data: data, line: 1, vars: map[string]string{}}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.vars, nil
+}
+
+type dotEnvParser struct {
+	data string
+	pos  int
+	line int
+	vars map[string]string
+}
+
+func (p *dotEnvParser) errorf(line int, format string, a ...any) error {
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, a...))
+}
+
+func (p *dotEnvParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *dotEnvParser) next() byte {
+	c := p.data[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+// skipSpace skips blanks, and newlines as well if lines is set
+func (p *dotEnvParser) skipSpace(lines bool) {
+	for !p.eof() {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\r':
+		case '\n':
+			if !lines {
+				return
+			}
+		default:
+			return
+		}
+		p.next()
+	}
+}
+
+func (p *dotEnvParser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+func (p *dotEnvParser) parse() error {
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return nil
+		}
+		if p.data[p.pos] == '#' {
+			p.skipLine()
+			continue
+		}
+		if err := p.parseAssignment(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *dotEnvParser) parseAssignment() error {
+	line := p.line
+	start := p.pos
+	for !p.eof() && p.data[p.pos] != '=' && p.data[p.pos] != '\n' {
+		p.next()
+	}
+	name := strings.TrimSpace(p.data[start:p.pos])
+	if p.eof() || p.data[p.pos] != '=' {
+		return p.errorf(line, "expected '=' after %q", name)
+	}
+	p.next()
+
+	if rest, ok := strings.CutPrefix(name, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+		name = strings.TrimSpace(rest)
+	}
+	if !isDotEnvName(name) {
+		return p.errorf(line, "invalid variable name %q", name)
+	}
+
+	p.skipSpace(false)
+
+	var (
+		val string
+		err error
+	)
+	if !p.eof() && (p.data[p.pos] == '\'' || p.data[p.pos] == '"') {
+		val, err = p.parseQuoted()
+		if err != nil {
+			return err
+		}
+		p.skipSpace(false)
+		if !p.eof() && p.data[p.pos] != '\n' && p.data[p.pos] != '#' {
+			return p.errorf(p.line, "unexpected characters after the quoted value of %q", name)
+		}
+		p.skipLine()
+	} else {
+		val = p.parseUnquoted()
+	}
+
+	p.vars[name] = val
+	return nil
+}
+
+func (p *dotEnvParser) parseQuoted() (string, error) {
+	line := p.line
+	quote := p.next()
+	var b strings.Builder
+
+	for !p.eof() {
+		c := p.next()
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case quote == '"' && c == '\\' && !p.eof():
+			e := p.next()
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(e)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+		case quote == '"' && c == '$':
+			p.expand(&b)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", p.errorf(line, "unterminated quoted value")
+}
+
+func (p *dotEnvParser) parseUnquoted() string {
+	var b strings.Builder
+
+	for !p.eof() && p.data[p.pos] != '\n' {
+		c := p.next()
+		switch {
+		case c == '#' && (b.Len() == 0 || strings.ContainsRune(" \t", rune(p.data[p.pos-2]))):
+			p.skipLine()
+			return strings.TrimSpace(b.String())
+		case c == '$':
+			p.expand(&b)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// expand writes the value of the variable named by the ${NAME} following
+// a '$' to b, or the '$' itself if no such reference follows
+func (p *dotEnvParser) expand(b *strings.Builder) {
+	rest := p.data[p.pos:]
+	end := strings.IndexAny(rest, "}\n")
+	if !strings.HasPrefix(rest, "{") || end < 0 || rest[end] != '}' || !isDotEnvName(rest[1:end]) {
+		b.WriteByte('$')
+		return
+	}
+
+	name := rest[1:end]
+	p.pos += end + 1
+
+	if val, ok := p.vars[name]; ok {
+		b.WriteString(val)
+	} else {
+		b.WriteString(os.Getenv(name))
+	}
+}
+
+func isDotEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c == '.', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExecSource is a ValueSource running a local command, such as a
+// credential helper like pass or op, and using its standard output with
+// surrounding white space trimmed as the value.
+//
+// The command is run at most once per run of the root command, on the
+// first lookup, and is killed when the context of the run is cancelled. A
+// command which can't be found or started, exits with a nonzero status,
+// times out or is cancelled is a source error.
+type ExecSource struct {
+	// Name is the name or path of the command
+	Name string
+	// Args are the arguments given to the command
+	Args []string
+	// Timeout is how long the command may run before being killed. Zero
+	// means no limit.
+	Timeout time.Duration
+
+	mu sync.Mutex
+	// ctx is the context of the current run, which the command is run with
+	ctx    context.Context
+	ran    bool
+	value  string
+	found  bool
+	runErr error
+}
+
+// Exec returns an ExecSource running the command name with args, without
+// a timeout
+func Exec(name string, args ...string) *ExecSource {
+	return &ExecSource{Name: name, Args: args}
+}
+
+func (e *ExecSource) Lookup() (string, bool) {
+	val, found, _ := e.LookupE()
+	return val, found
+}
+
+// LookupE runs the command, unless it has already been run in the
+// current run, and returns its output
+func (e *ExecSource) LookupE() (string, bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.ran {
+		e.value, e.found, e.runErr = e.run()
+		e.ran = true
+	}
+	return e.value, e.found, e.runErr
+}
+
+func (e *ExecSource) run() (string, bool, error) {
+	tracef("running command %[1]q for value source", e.commandLine())
+
+	parent := e.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx := parent
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, e.Name, e.Args...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	err := c.Run()
+	if err := parent.Err(); err != nil {
+		return "", false, err
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", false, fmt.Errorf("timed out after %s", e.Timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", false, fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", false, err
+	}
+
+	return strings.TrimSpace(stdout.String()), true, nil
+}
+
+// startRun forgets the output of the command in a previous run, and
+// keeps the context of the run to run the command with
+func (e *ExecSource) startRun(ctx context.Context, _ []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.ctx = ctx
+	e.ran = false
+	e.value, e.found, e.runErr = "", false, nil
+}
+
+// sensitive returns true, as commands are typically run to read secrets
+func (e *ExecSource) sensitive() bool {
+	return true
+}
+
+// cloneSource returns an ExecSource running the same command, which
+// hasn't run yet
+func (e *ExecSource) cloneSource(*sourceCloner) any {
+	return &ExecSource{Name: e.Name, Args: slices.Clone(e.Args), Timeout: e.Timeout}
+}
+
+// commandLine returns the command with its arguments, quoting the ones
+// which contain spaces or are empty
+func (e *ExecSource) commandLine() string {
+	parts := []string{e.Name}
+	for _, arg := range e.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = fmt.Sprintf("%q", arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (e *ExecSource) String() string {
+	return fmt.Sprintf("command %[1]q", e.commandLine())
+}
+
+func (e *ExecSource) GoString() string {
+	return fmt.Sprintf("&ExecSource{Name:%[1]q, Args:%#[2]v, Timeout:%[3]s}", e.Name, e.Args, e.Timeout)
+}
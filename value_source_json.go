@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// JSONConfig is a MapSource reading its values from a JSON configuration
+// file, whose path may be given by a flag, see ConfigFlag. The file is
+// read on the first lookup of each run and its values may be looked up
+// with dot-separated paths into the nested objects of the file.
+//
+// A JSONConfig keeps the state of a run and so must not be shared by
+// commands which are run concurrently.
+type JSONConfig struct {
+	// Path is the path of the file read when no other one is given with
+	// the flag returned by ConfigFlag
+	Path string
+
+	flag    *StringFlag
+	argPath string
+	hasArg  bool
+	loaded  bool
+	ms      MapSource
+	err     error
+}
+
+// NewJSONConfig returns a JSONConfig reading the file at path, unless
+// another one is given with the flag returned by its ConfigFlag
+func NewJSONConfig(path string) *JSONConfig {
+	return &JSONConfig{Path: path}
+}
+
+// ConfigFlag returns the --config flag naming the file to read instead of
+// Path. The flag may be given anywhere on the command line, including after
+// a sub-command, and may itself be read from sources such as environment
+// variables: the path is found before the values of any other flag are
+// looked up in the file.
+//
+// The returned flag may be modified, for instance to give it other names,
+// a usage text or sources, before being added to the root command.
+func (c *JSONConfig) ConfigFlag() *StringFlag {
+	if c.flag == nil {
+		c.flag = &StringFlag{
+			Name:  "config",
+			Usage: "load configuration from `FILE`",
+			Value: c.Path,
+		}
+	}
+	return c.flag
+}
+
+// Key returns a ValueSource looking up the given dot-separated key in the
+// configuration file
+func (c *JSONConfig) Key(key string) ValueSource {
+	return NewMapValueSource(key, c)
+}
+
+func (c *JSONConfig) String() string {
+	path, _ := c.path()
+	return fmt.Sprintf("json file %[1]q", path)
+}
+
+func (c *JSONConfig) GoString() string {
+	return fmt.Sprintf("&JSONConfig{Path:%[1]q}", c.Path)
+}
+
+// Lookup returns the value at the given dot-separated path in the
+// configuration file. Nothing is found when the file can't be read, see
+// LookupE.
+func (c *JSONConfig) Lookup(name string) (any, bool) {
+	v, found, _ := c.LookupE(name)
+	return v, found
+}
+
+// LookupE returns the value at the given dot-separated path in the
+// configuration file. When Path doesn't exist, the file holds no values,
+// and a null value is not found, while a file given with the config flag
+// which doesn't exist, a file which can't be read or isn't a JSON object,
+// an array which can't be read as a comma separated list and an object
+// are errors.
+func (c *JSONConfig) LookupE(name string) (any, bool, error) {
+	if !c.loaded {
+		c.load()
+	}
+	if c.err != nil {
+		return nil, false, c.err
+	}
+	v, found := c.ms.Lookup(name)
+	switch v := v.(type) {
+	case nil:
+		return nil, false, nil
+	case error:
+		return nil, false, v
+	case map[string]any, map[any]any:
+		return nil, false, fmt.Errorf("%[1]q is an object, not a value", name)
+	}
+	return v, found, nil
+}
+
+// path returns the path given on the command line, or else read by the
+// config flag from its sources, or else Path, along with whether it was
+// given explicitly rather than being Path
+func (c *JSONConfig) path() (string, bool) {
+	if c.hasArg {
+		return c.argPath, true
+	}
+	if c.flag != nil {
+		if c.flag.IsSet() {
+			return c.flag.Get().(string), true
+		}
+		if path, ok := c.flag.Sources.Lookup(); ok {
+			return path, true
+		}
+	}
+	return c.Path, false
+}
+
+func (c *JSONConfig) load() {
+	path, explicit := c.path()
+	tracef("loading json config %[1]q", path)
+
+	m := map[any]any{}
+	c.ms = NewMapSource(path, m)
+	c.loaded = true
+	c.err = nil
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return
+	}
+	if err != nil {
+		c.err = err
+		return
+	}
+
+	var obj map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		c.err = fmt.Errorf("invalid JSON: %w", err)
+		return
+	}
+	for k, v := range obj {
+		m[k] = jsonValue(v)
+	}
+}
+
+// jsonValue converts the arrays of a decoded JSON value to the comma
+// separated form read by slice flags. An array which has no such form,
+// because an element isn't a string, number or boolean or contains a
+// comma, is replaced by an error returned when it is looked up.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case []any:
+		vals := make([]string, len(v))
+		for i, el := range v {
+			switch el := el.(type) {
+			case string:
+				if strings.Contains(el, ",") {
+					return fmt.Errorf("array element %[1]d %[2]q contains a comma", i, el)
+				}
+				vals[i] = el
+			case json.Number, bool:
+				vals[i] = fmt.Sprintf("%v", el)
+			default:
+				return fmt.Errorf("array element %[1]d is not a string, number or boolean", i)
+			}
+		}
+		return strings.Join(vals, ",")
+	case map[string]any:
+		for k, el := range v {
+			v[k] = jsonValue(el)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// reload forgets the file read so far, to read it again on the next lookup
+func (c *JSONConfig) reload() {
+	c.loaded = false
+	c.ms = nil
+	c.err = nil
+}
+
+// cloneSource returns a JSONConfig reading the same default file, which
+// has read nothing yet, and whose config flag is the copy of the flag of c
+func (c *JSONConfig) cloneSource(sc *sourceCloner) any {
+	clone := &JSONConfig{Path: c.Path, flag: c.flag}
+	if fl, ok := sc.flags[c.flag].(*StringFlag); ok && c.flag != nil {
+		clone.flag = fl
+	}
+	return clone
+}
+
+// startRun forgets the file read by a previous run and looks for the
+// config flag on the whole command line, so that its value is known
+// before any flag is parsed
+func (c *JSONConfig) startRun(_ context.Context, args []string) {
+	c.reload()
+	c.hasArg = false
+
+	if c.flag == nil || len(args) == 0 {
+		return
+	}
+
+	names := c.flag.Names()
+	args = args[1:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		for _, n := range names {
+			if name != n {
+				continue
+			}
+			if !hasValue {
+				if i+1 >= len(args) {
+					return
+				}
+				i++
+				value = args[i]
+			}
+			c.argPath, c.hasArg = value, true
+		}
+	}
+}
@@ -1,11 +1,18 @@
 package cli
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"io/fs"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -130,6 +137,128 @@ func TestFilePaths(t *testing.T) {
 	r.Contains(src.String(), fmt.Sprintf("%[1]q", fileName))
 }
 
+func TestFileValueSourceOptions(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("USERPROFILE", dir)
+	t.Setenv("URFAVE_CLI_TEST_DIR", dir)
+
+	write := func(name, content string, perm os.FileMode) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), perm))
+		require.NoError(t, os.Chmod(path, perm))
+	}
+	write("token", "  s3cr3t\r\n", 0o600)
+	write("shared", "s3cr3t\n", 0o644)
+	write("large", strings.Repeat("x", 100), 0o600)
+
+	tests := []struct {
+		name  string
+		path  string
+		opts  FileOptions
+		val   string
+		found bool
+		err   string
+		unix  bool
+	}{
+		{
+			name:  "raw",
+			path:  filepath.Join(dir, "token"),
+			val:   "  s3cr3t\r\n",
+			found: true,
+		},
+		{
+			name:  "trim newline",
+			path:  filepath.Join(dir, "token"),
+			opts:  FileOptions{TrimNewline: true},
+			val:   "  s3cr3t",
+			found: true,
+		},
+		{
+			name:  "trim space",
+			path:  filepath.Join(dir, "token"),
+			opts:  FileOptions{TrimSpace: true},
+			val:   "s3cr3t",
+			found: true,
+		},
+		{
+			name:  "within max size",
+			path:  filepath.Join(dir, "large"),
+			opts:  FileOptions{MaxSize: 100},
+			val:   strings.Repeat("x", 100),
+			found: true,
+		},
+		{
+			name: "above max size",
+			path: filepath.Join(dir, "large"),
+			opts: FileOptions{MaxSize: 99},
+			err:  "file is larger than 99 bytes",
+		},
+		{
+			name:  "sensitive private file",
+			path:  filepath.Join(dir, "token"),
+			opts:  FileOptions{Sensitive: true, TrimSpace: true},
+			val:   "s3cr3t",
+			found: true,
+		},
+		{
+			name:  "expand home",
+			path:  "~/token",
+			opts:  FileOptions{ExpandPath: true, TrimSpace: true},
+			val:   "s3cr3t",
+			found: true,
+		},
+		{
+			name:  "expand variable",
+			path:  "${URFAVE_CLI_TEST_DIR}/token",
+			opts:  FileOptions{ExpandPath: true, TrimSpace: true},
+			val:   "s3cr3t",
+			found: true,
+		},
+		{
+			name: "no expansion",
+			path: "$URFAVE_CLI_TEST_DIR/token",
+		},
+		{
+			name: "missing",
+			path: filepath.Join(dir, "missing"),
+			opts: FileOptions{Sensitive: true, MaxSize: 10},
+		},
+		{
+			name: "sensitive shared file",
+			path: filepath.Join(dir, "shared"),
+			opts: FileOptions{Sensitive: true},
+			err:  "file holding a sensitive value is readable by group or others (mode 0644)",
+			unix: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.unix && runtime.GOOS == "windows" {
+				t.Skip("file permissions are not checked on windows")
+			}
+			val, found, err := FileWithOptions(test.path, test.opts).(ErrorValueSource).LookupE()
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.found, found)
+			assert.Equal(t, test.val, val)
+		})
+	}
+
+	t.Run("chain", func(t *testing.T) {
+		vsc := FilesWithOptions(FileOptions{TrimNewline: true, ExpandPath: true}, "~/missing", "~/shared")
+		val, src, found := vsc.LookupWithSource()
+		assert.True(t, found)
+		assert.Equal(t, "s3cr3t", val)
+		assert.Equal(t, `file "~/shared"`, src.String())
+		assert.Equal(t, `&fileValueSource{Path:"~/shared", Options:{TrimNewline:true TrimSpace:false MaxSize:0 Sensitive:false ExpandPath:true}}`, src.GoString())
+	})
+}
+
 func TestValueSourceChainEnvKeys(t *testing.T) {
 	chain := NewValueSourceChain(
 		&staticValueSource{"hello"},
@@ -180,6 +309,93 @@ func TestValueSourceChain(t *testing.T) {
 	})
 }
 
+func TestValueSourceChainLookupWithSourceE(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "value")
+	require.NoError(t, os.WriteFile(fileName, []byte("found"), 0o644))
+
+	vsc := Files(filepath.Join(dir, "missing"), dir, fileName)
+
+	t.Run("stops at the failing source", func(t *testing.T) {
+		_, src, found, err := vsc.LookupWithSourceE()
+		assert.False(t, found)
+		assert.Equal(t, File(dir).String(), src.String())
+
+		var vsErr *ValueSourceError
+		require.ErrorAs(t, err, &vsErr)
+		assert.Equal(t, File(dir).String(), vsErr.Source.String())
+		assert.ErrorContains(t, err, fmt.Sprintf("could not read value from file %[1]q: ", dir))
+	})
+
+	t.Run("without errors skips it", func(t *testing.T) {
+		val, src, found := vsc.LookupWithSource()
+		assert.True(t, found)
+		assert.Equal(t, "found", val)
+		assert.Equal(t, File(fileName).String(), src.String())
+	})
+
+	t.Run("missing files are not found", func(t *testing.T) {
+		vsc := Files(filepath.Join(dir, "missing"))
+		_, _, found, err := vsc.LookupWithSourceE()
+		assert.False(t, found)
+		assert.NoError(t, err)
+	})
+}
+
+func TestValueSourceErrors(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"name": `), 0o644))
+	envPath := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(envPath, []byte("NAME=\"unterminated\n"), 0o644))
+
+	tests := []struct {
+		name    string
+		sources ValueSourceChain
+		err     string
+	}{
+		{
+			name:    "unreadable file",
+			sources: Files(dir),
+			err:     fmt.Sprintf("could not read value from file %[1]q for flag name: ", dir),
+		},
+		{
+			name:    "invalid json",
+			sources: NewValueSourceChain(NewJSONConfig(jsonPath).Key("name")),
+			err:     fmt.Sprintf("could not read value from key \"name\" from json file %[1]q for flag name: invalid JSON: unexpected EOF", jsonPath),
+		},
+		{
+			name:    "invalid dotenv",
+			sources: NewDotEnv(envPath).EnvVars("NAME"),
+			err:     fmt.Sprintf("could not read value from variable \"NAME\" from dotenv file %[1]q for flag name: line 1: unterminated quoted value", envPath),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var errWriter bytes.Buffer
+			cmd := &Command{
+				Writer:    io.Discard,
+				ErrWriter: &errWriter,
+				Flags: []Flag{
+					&StringFlag{Name: "name", Value: "default", Sources: test.sources},
+				},
+				Action: func(context.Context, *Command) error {
+					t.Fatal("action should not run")
+					return nil
+				},
+			}
+
+			err := cmd.Run(buildTestContext(t), []string{"app"})
+			var vsErr *ValueSourceError
+			require.ErrorAs(t, err, &vsErr)
+			assert.Equal(t, "name", vsErr.Flag)
+			assert.ErrorContains(t, err, test.err)
+			assert.Contains(t, errWriter.String(), "Incorrect Usage: "+test.err)
+		})
+	}
+}
+
 type staticValueSource struct {
 	v string
 }
@@ -346,3 +562,558 @@ func TestMapValueSourceStringer(t *testing.T) {
 	assert.Equal(t, `&mapValueSource{key:"bar", src:&mapSource{name:"test"}}`, mvs.GoString())
 	assert.Equal(t, `key "bar" from map source "test"`, mvs.String())
 }
+
+func TestJSONConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"name": "x",
+		"port": 8080,
+		"tags": ["a", "b"],
+		"db": {"host": "localhost", "tls": true}
+	}`), 0o644))
+
+	tests := []struct {
+		key   string
+		val   string
+		found bool
+	}{
+		{key: "name", val: "x", found: true},
+		{key: "port", val: "8080", found: true},
+		{key: "tags", val: "a,b", found: true},
+		{key: "db.host", val: "localhost", found: true},
+		{key: "db.tls", val: "true", found: true},
+		{key: "db.port"},
+		{key: "missing.key"},
+	}
+
+	cfg := NewJSONConfig(path)
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			val, found := cfg.Key(test.key).Lookup()
+			assert.Equal(t, test.found, found)
+			assert.Equal(t, test.val, val)
+		})
+	}
+
+	assert.Equal(t, fmt.Sprintf("key %[1]q from json file %[2]q", "db.host", path), cfg.Key("db.host").String())
+	assert.Equal(t, fmt.Sprintf("&JSONConfig{Path:%[1]q}", path), cfg.GoString())
+
+	t.Run("missing file", func(t *testing.T) {
+		_, found, err := NewJSONConfig(filepath.Join(dir, "missing.json")).LookupE("name")
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("null and object values", func(t *testing.T) {
+		path := filepath.Join(dir, "values.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"name": null, "db": {"host": null, "tls": {"on": true}}}`), 0o644))
+		cfg := NewJSONConfig(path)
+
+		for _, key := range []string{"name", "db.host"} {
+			val, found, err := cfg.LookupE(key)
+			require.NoError(t, err, key)
+			assert.False(t, found, key)
+			assert.Nil(t, val, key)
+		}
+
+		for _, key := range []string{"db", "db.tls"} {
+			_, found, err := cfg.LookupE(key)
+			assert.False(t, found, key)
+			assert.EqualError(t, err, fmt.Sprintf("%q is an object, not a value", key))
+		}
+
+		var name string
+		cmd := &Command{
+			Flags: []Flag{
+				&StringFlag{Name: "name", Value: "default", Sources: NewValueSourceChain(cfg.Key("name"))},
+			},
+			Action: func(_ context.Context, cmd *Command) error {
+				name = cmd.String("name")
+				return nil
+			},
+		}
+		require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+		assert.Equal(t, "default", name)
+	})
+
+	t.Run("invalid arrays", func(t *testing.T) {
+		path := filepath.Join(dir, "arrays.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{
+			"flags": [true, 1.5, "x"],
+			"commas": ["a", "b,c"],
+			"objects": [{"a": 1}],
+			"arrays": [[1, 2]],
+			"nulls": [null]
+		}`), 0o644))
+		cfg := NewJSONConfig(path)
+
+		val, found, err := cfg.LookupE("flags")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "true,1.5,x", val)
+
+		for key, expectedErr := range map[string]string{
+			"commas":  `array element 1 "b,c" contains a comma`,
+			"objects": "array element 0 is not a string, number or boolean",
+			"arrays":  "array element 0 is not a string, number or boolean",
+			"nulls":   "array element 0 is not a string, number or boolean",
+		} {
+			_, found, err := cfg.LookupE(key)
+			assert.False(t, found, key)
+			assert.EqualError(t, err, expectedErr, key)
+		}
+	})
+}
+
+func TestJSONConfigFlag(t *testing.T) {
+	dir := t.TempDir()
+	defaultPath := filepath.Join(dir, "default.json")
+	otherPath := filepath.Join(dir, "other.json")
+	require.NoError(t, os.WriteFile(defaultPath, []byte(`{"region": "eu", "deploy": {"replicas": 1}}`), 0o644))
+	require.NoError(t, os.WriteFile(otherPath, []byte(`{"region": "us", "deploy": {"replicas": 3}}`), 0o644))
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		region   string
+		replicas int
+	}{
+		{
+			name:     "default file",
+			args:     []string{"app", "deploy"},
+			region:   "eu",
+			replicas: 1,
+		},
+		{
+			name:     "flag before sub-command",
+			args:     []string{"app", "--config", otherPath, "deploy"},
+			region:   "us",
+			replicas: 3,
+		},
+		{
+			name:     "flag after sub-command",
+			args:     []string{"app", "deploy", "--config=" + otherPath},
+			region:   "us",
+			replicas: 3,
+		},
+		{
+			name:     "flag from environment",
+			args:     []string{"app", "deploy"},
+			env:      otherPath,
+			region:   "us",
+			replicas: 3,
+		},
+		{
+			name:     "command line overrides file",
+			args:     []string{"app", "--region", "ap", "deploy", "-c", otherPath},
+			region:   "ap",
+			replicas: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv("APP_CONFIG", test.env)
+			}
+
+			cfg := NewJSONConfig(defaultPath)
+			configFlag := cfg.ConfigFlag()
+			configFlag.Aliases = []string{"c"}
+			configFlag.Sources = EnvVars("APP_CONFIG")
+
+			var region string
+			var replicas int
+			cmd := &Command{
+				Name: "app",
+				Flags: []Flag{
+					&StringFlag{Name: "region", Sources: NewValueSourceChain(cfg.Key("region"))},
+					configFlag,
+				},
+				Commands: []*Command{
+					{
+						Name: "deploy",
+						Flags: []Flag{
+							&IntFlag{Name: "replicas", Sources: NewValueSourceChain(cfg.Key("deploy.replicas"))},
+						},
+						Action: func(_ context.Context, cmd *Command) error {
+							region = cmd.String("region")
+							replicas = cmd.Int("replicas")
+							return nil
+						},
+					},
+				},
+			}
+
+			require.NoError(t, cmd.Run(buildTestContext(t), test.args))
+			assert.Equal(t, test.region, region)
+			assert.Equal(t, test.replicas, replicas)
+		})
+	}
+}
+
+func TestJSONConfigFlagMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	missingPath := filepath.Join(dir, "missing.json")
+
+	tests := []struct {
+		name string
+		args []string
+		env  string
+	}{
+		{
+			name: "flag",
+			args: []string{"app", "--config", missingPath},
+		},
+		{
+			name: "flag from environment",
+			args: []string{"app"},
+			env:  missingPath,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.env != "" {
+				t.Setenv("APP_CONFIG", test.env)
+			}
+
+			cfg := NewJSONConfig(filepath.Join(dir, "default.json"))
+			configFlag := cfg.ConfigFlag()
+			configFlag.Sources = EnvVars("APP_CONFIG")
+
+			cmd := &Command{
+				Name: "app",
+				Flags: []Flag{
+					configFlag,
+					&StringFlag{Name: "region", Sources: NewValueSourceChain(cfg.Key("region"))},
+				},
+				Action: func(context.Context, *Command) error {
+					return nil
+				},
+			}
+
+			err := cmd.Run(buildTestContext(t), test.args)
+			require.ErrorIs(t, err, fs.ErrNotExist)
+
+			var vsErr *ValueSourceError
+			require.ErrorAs(t, err, &vsErr)
+			assert.Equal(t, "region", vsErr.Flag)
+		})
+	}
+
+	t.Run("default file", func(t *testing.T) {
+		cfg := NewJSONConfig(filepath.Join(dir, "default.json"))
+		cmd := &Command{
+			Name: "app",
+			Flags: []Flag{
+				cfg.ConfigFlag(),
+				&StringFlag{Name: "region", Value: "eu", Sources: NewValueSourceChain(cfg.Key("region"))},
+			},
+			Action: func(_ context.Context, cmd *Command) error {
+				assert.Equal(t, "eu", cmd.String("region"))
+				return nil
+			},
+		}
+
+		require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+	})
+}
+
+func TestJSONConfigReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"name": "first"}`), 0o644))
+
+	cfg := NewJSONConfig(path)
+	var name string
+	cmd := &Command{
+		Flags: []Flag{
+			cfg.ConfigFlag(),
+			&StringFlag{Name: "name", Sources: NewValueSourceChain(cfg.Key("name"))},
+		},
+		Action: func(_ context.Context, cmd *Command) error {
+			name = cmd.String("name")
+			return nil
+		},
+	}
+
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+	assert.Equal(t, "first", name)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"name": "second"}`), 0o644))
+	require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+	assert.Equal(t, "second", name)
+}
+
+func TestParseDotEnv(t *testing.T) {
+	t.Setenv("URFAVE_CLI_TEST_HOME", "/home/test")
+
+	tests := []struct {
+		name string
+		data string
+		vars map[string]string
+		err  string
+	}{
+		{
+			name: "empty",
+			vars: map[string]string{},
+		},
+		{
+			name: "comments and blank lines",
+			data: "# a comment\n\n  # indented comment\nA=1\n",
+			vars: map[string]string{"A": "1"},
+		},
+		{
+			name: "export prefix",
+			data: "export A=1\nexport\tB = 2\nexported=3",
+			vars: map[string]string{"A": "1", "B": "2", "exported": "3"},
+		},
+		{
+			name: "unquoted",
+			data: "A= some value  # comment\nB=a#b\nC=\nD=#empty",
+			vars: map[string]string{"A": "some value", "B": "a#b", "C": "", "D": ""},
+		},
+		{
+			name: "text after quoted value",
+			data: "A='1'\nB='it''s'",
+			err:  "line 2: unexpected characters after the quoted value of \"B\"",
+		},
+		{
+			name: "single quoted literal",
+			data: `A='a \n ${B} # c' # comment`,
+			vars: map[string]string{"A": `a \n ${B} # c`},
+		},
+		{
+			name: "double quoted escapes",
+			data: `A="a\tb\n\"c\" \\ \$HOME \x"`,
+			vars: map[string]string{"A": "a\tb\n\"c\" \\ $HOME \\x"},
+		},
+		{
+			name: "multi-line",
+			data: "A=\"first\nsecond\"\nB='third\nfourth'\nC=5",
+			vars: map[string]string{"A": "first\nsecond", "B": "third\nfourth", "C": "5"},
+		},
+		{
+			name: "interpolation",
+			data: "A=x\nB=${A}-${URFAVE_CLI_TEST_HOME}\nC=\"${B}/y\"\nD=${URFAVE_CLI_TEST_MISSING}z\nE=$A ${ not",
+			vars: map[string]string{"A": "x", "B": "x-/home/test", "C": "x-/home/test/y", "D": "z", "E": "$A ${ not"},
+		},
+		{
+			name: "CRLF line endings",
+			data: "A=1\r\nB=\"2\"\r\n",
+			vars: map[string]string{"A": "1", "B": "2"},
+		},
+		{
+			name: "missing equals",
+			data: "A=1\nB\n",
+			err:  "line 2: expected '=' after \"B\"",
+		},
+		{
+			name: "invalid name",
+			data: "A B=1",
+			err:  "line 1: invalid variable name \"A B\"",
+		},
+		{
+			name: "unterminated quote",
+			data: "A=1\nB=\"2\n3",
+			err:  "line 2: unterminated quoted value",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vars, err := parseDotEnv(test.data)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.vars, vars)
+		})
+	}
+}
+
+func TestDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("export APP_NAME=file\nAPP_PORT=8080\n"), 0o644))
+
+	d := NewDotEnv(path)
+	src := d.EnvVar("APP_NAME")
+	val, found := src.Lookup()
+	assert.True(t, found)
+	assert.Equal(t, "file", val)
+	assert.Equal(t, fmt.Sprintf(`variable "APP_NAME" from dotenv file %[1]q`, path), src.String())
+	assert.Equal(t, fmt.Sprintf(`&dotEnvValueSource{Key:"APP_NAME", src:&DotEnv{Path:%[1]q}}`, path), src.GoString())
+
+	_, found = d.EnvVar("APP_MISSING").Lookup()
+	assert.False(t, found)
+
+	_, found = NewDotEnv(path + ".missing").Lookup("APP_NAME")
+	assert.False(t, found)
+
+	t.Run("environment overrides file", func(t *testing.T) {
+		vsc := d.EnvVars("APP_NAME", "APP_PORT")
+		assert.Equal(t, []string{"APP_NAME", "APP_PORT"}, vsc.EnvKeys())
+
+		val, src, found := vsc.LookupWithSource()
+		assert.True(t, found)
+		assert.Equal(t, "file", val)
+		assert.Equal(t, d.EnvVar("APP_NAME").String(), src.String())
+
+		t.Setenv("APP_NAME", "env")
+		val, src, found = vsc.LookupWithSource()
+		assert.True(t, found)
+		assert.Equal(t, "env", val)
+		assert.Equal(t, `environment variable "APP_NAME"`, src.String())
+	})
+
+	t.Run("help text", func(t *testing.T) {
+		fl := &IntFlag{Name: "port", Sources: d.EnvVars("APP_PORT")}
+		assert.Contains(t, fl.String(), "[$APP_PORT]")
+
+		cmd := &Command{
+			Flags: []Flag{fl},
+		}
+		require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+		assert.Equal(t, 8080, cmd.Int("port"))
+	})
+
+	t.Run("read again on every run", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".env")
+		require.NoError(t, os.WriteFile(path, []byte("APP_PORT=8080\n"), 0o644))
+
+		d := NewDotEnv(path)
+		cmd := &Command{
+			Flags: []Flag{
+				&IntFlag{Name: "port", Sources: d.EnvVars("APP_PORT")},
+			},
+		}
+		require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+		assert.Equal(t, 8080, cmd.Int("port"))
+
+		require.NoError(t, os.WriteFile(path, []byte("APP_PORT=9090\n"), 0o644))
+		require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+		assert.Equal(t, 9090, cmd.Int("port"))
+	})
+}
+
+func TestExecSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the tests run commands with sh")
+	}
+
+	tests := []struct {
+		name  string
+		src   *ExecSource
+		val   string
+		found bool
+		err   string
+	}{
+		{
+			name:  "trimmed output",
+			src:   Exec("sh", "-c", `printf '  t0k3n\n\n'`),
+			val:   "t0k3n",
+			found: true,
+		},
+		{
+			name: "nonzero exit",
+			src:  Exec("sh", "-c", "echo 'no such entry' >&2; exit 3"),
+			err:  "exit status 3: no such entry",
+		},
+		{
+			name: "timeout",
+			src:  &ExecSource{Name: "sleep", Args: []string{"5"}, Timeout: 50 * time.Millisecond},
+			err:  "timed out after 50ms",
+		},
+		{
+			name: "not found",
+			src:  Exec("urfave-cli-test-no-such-command"),
+			err:  `exec: "urfave-cli-test-no-such-command": executable file not found in $PATH`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, found, err := test.src.LookupE()
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.found, found)
+			assert.Equal(t, test.val, val)
+		})
+	}
+
+	t.Run("stringers", func(t *testing.T) {
+		src := &ExecSource{Name: "op", Args: []string{"read", "op://vault/my item/token"}, Timeout: time.Second}
+		assert.Equal(t, `command "op read \"op://vault/my item/token\""`, src.String())
+		assert.Equal(t, `&ExecSource{Name:"op", Args:[]string{"read", "op://vault/my item/token"}, Timeout:1s}`, src.GoString())
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		t.Setenv("APP_TOKEN", "from-env")
+
+		vsc := NewValueSourceChain(EnvVar("APP_TOKEN"), Exec("urfave-cli-test-no-such-command"))
+		val, src, found, err := vsc.LookupWithSourceE()
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "from-env", val)
+		assert.Equal(t, `environment variable "APP_TOKEN"`, src.String())
+
+		vsc = NewValueSourceChain(Exec("sh", "-c", "exit 1"), EnvVar("APP_TOKEN"))
+		_, src, _, err = vsc.LookupWithSourceE()
+		assert.EqualError(t, err, `could not read value from command "sh -c \"exit 1\"": exit status 1`)
+		assert.Equal(t, `command "sh -c \"exit 1\""`, src.String())
+	})
+
+	t.Run("cached per run", func(t *testing.T) {
+		counter := filepath.Join(t.TempDir(), "counter")
+		src := Exec("sh", "-c", `echo run >> "$0"; wc -l < "$0"`, counter)
+
+		var token, other string
+		cmd := &Command{
+			Flags: []Flag{
+				&StringFlag{Name: "token", Sources: NewValueSourceChain(src)},
+				&StringFlag{Name: "other", Sources: NewValueSourceChain(src)},
+			},
+			Action: func(_ context.Context, cmd *Command) error {
+				token, other = cmd.String("token"), cmd.String("other")
+				return nil
+			},
+		}
+
+		require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+		assert.Equal(t, "1", token)
+		assert.Equal(t, "1", other)
+
+		require.NoError(t, cmd.Run(buildTestContext(t), []string{"app"}))
+		assert.Equal(t, "2", token)
+		assert.Equal(t, "2", other)
+	})
+
+	t.Run("cancelled run", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(buildTestContext(t))
+		cancel()
+
+		cmd := &Command{
+			Flags: []Flag{
+				&StringFlag{Name: "token", Sources: NewValueSourceChain(Exec("sleep", "5"))},
+			},
+			Action: func(context.Context, *Command) error {
+				return nil
+			},
+		}
+
+		start := time.Now()
+		err := cmd.Run(ctx, []string{"app"})
+		assert.Less(t, time.Since(start), 5*time.Second)
+		require.ErrorIs(t, err, context.Canceled)
+
+		var vsErr *ValueSourceError
+		require.ErrorAs(t, err, &vsErr)
+		assert.Equal(t, "token", vsErr.Flag)
+	})
+}